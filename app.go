@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fast-clean-x/backend/cleaner"
 	"fast-clean-x/backend/config"
+	"fast-clean-x/backend/globalcache"
+	"fast-clean-x/backend/history"
 	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/quarantine"
 	"fast-clean-x/backend/scanner"
 	"fmt"
 	"os/exec"
 	"runtime"
+	"time"
 
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -19,6 +24,8 @@ type App struct {
 	configManager  *config.Manager
 	currentScanner *scanner.Scanner
 	currentCleaner *cleaner.Cleaner
+	watcher        *scanner.Watcher
+	lastResult     *models.ScanResult // 最近一次完整扫描的结果，用于启动监听
 }
 
 // NewApp creates a new App application struct
@@ -32,6 +39,14 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+
+	// 清理过期的隔离记录
+	go a.purgeExpiredQuarantine()
+}
+
+// shutdown is called when the app is about to quit
+func (a *App) shutdown(ctx context.Context) {
+	a.StopWatch()
 }
 
 // GetConfig 获取配置
@@ -39,6 +54,11 @@ func (a *App) GetConfig() *models.Config {
 	return a.configManager.GetConfig()
 }
 
+// GetConfigNotices 获取加载配置时需要告知用户的变化，如与内置规则重名而被重命名的自定义规则
+func (a *App) GetConfigNotices() []string {
+	return a.configManager.Notices()
+}
+
 // UpdateConfig 更新配置
 func (a *App) UpdateConfig(cfg *models.Config) error {
 	return a.configManager.UpdateConfig(cfg)
@@ -54,6 +74,16 @@ func (a *App) RemoveScanPath(path string) error {
 	return a.configManager.RemoveScanPath(path)
 }
 
+// AddProtectedPath 添加受保护的路径，该路径及其中的内容永远不会被清理
+func (a *App) AddProtectedPath(path string) error {
+	return a.configManager.AddProtectedPath(path)
+}
+
+// RemoveProtectedPath 移除受保护的路径
+func (a *App) RemoveProtectedPath(path string) error {
+	return a.configManager.RemoveProtectedPath(path)
+}
+
 // AddIgnorePattern 添加忽略模式
 func (a *App) AddIgnorePattern(pattern string) error {
 	return a.configManager.AddIgnorePattern(pattern)
@@ -69,8 +99,44 @@ func (a *App) UpdateScanRule(ruleName string, enabled bool) error {
 	return a.configManager.UpdateScanRule(ruleName, enabled)
 }
 
+// AddScanRule 添加自定义扫描规则
+func (a *App) AddScanRule(rule models.ScanRule) error {
+	return a.configManager.AddScanRule(rule)
+}
+
+// UpdateScanRuleDefinition 修改自定义扫描规则的定义
+func (a *App) UpdateScanRuleDefinition(ruleName string, rule models.ScanRule) error {
+	return a.configManager.UpdateScanRuleDefinition(ruleName, rule)
+}
+
+// DeleteScanRule 删除自定义扫描规则
+func (a *App) DeleteScanRule(ruleName string) error {
+	return a.configManager.DeleteScanRule(ruleName)
+}
+
+// SetDeleteMode 设置默认删除方式
+func (a *App) SetDeleteMode(mode string) error {
+	return a.configManager.SetDeleteMode(mode)
+}
+
+// SetSizeMode 设置大小统计方式（apparent 或 disk）
+func (a *App) SetSizeMode(mode string) error {
+	return a.configManager.SetSizeMode(mode)
+}
+
 // StartScan 开始扫描
+// 使用增量扫描缓存，没有变化的目录不再重新读取和统计大小
 func (a *App) StartScan() (*models.ScanResult, error) {
+	return a.scan(false)
+}
+
+// StartFullScan 忽略增量扫描缓存，完整重新扫描所有目录
+func (a *App) StartFullScan() (*models.ScanResult, error) {
+	return a.scan(true)
+}
+
+// scan 执行扫描，full 为 true 时丢弃上次扫描的缓存
+func (a *App) scan(full bool) (*models.ScanResult, error) {
 	cfg := a.configManager.GetConfig()
 
 	// 创建扫描器
@@ -79,20 +145,126 @@ func (a *App) StartScan() (*models.ScanResult, error) {
 		cfg.IgnorePatterns,
 		cfg.GlobalPathExcludes,
 	)
+	a.currentScanner.SetSizeMode(cfg.SizeMode)
+
+	// 缓存无法打开时不影响扫描，只是退化为完整扫描
+	cache, cacheErr := scanner.OpenCache()
+	if cacheErr == nil {
+		if full {
+			cache.Reset()
+		}
+		a.currentScanner.SetCache(cache)
+	}
+
+	// 实时推送找到的目录和计算完成的大小
+	a.currentScanner.SetItemHandler(a.emitScanItem)
 
 	// 启动进度监听
 	go a.listenScanProgress()
 
 	// 执行扫描
+	startTime := time.Now()
 	result, err := a.currentScanner.Scan(cfg.ScanPaths)
 
 	// 关闭扫描器
 	a.currentScanner.Close()
 	a.currentScanner = nil
 
+	// 只有完整扫描了所有路径才更新缓存，避免部分结果覆盖上次的缓存
+	if cacheErr == nil && result != nil && result.Complete {
+		_ = cache.Save()
+	}
+	if result != nil && result.Complete {
+		a.lastResult = result
+	}
+
+	// 记录扫描历史
+	_ = a.configManager.SetLastScanTime(startTime)
+	_ = history.Save(history.NewScanRecord(cfg.ScanPaths, result, startTime, err))
+
+	// 取消时返回已找到的部分结果，前端通过 Cancelled 字段区分
+	if errors.Is(err, scanner.ErrCancelled) {
+		return result, nil
+	}
 	return result, err
 }
 
+// StartWatch 开始监听扫描路径的文件系统变化，实时更新扫描结果
+// 以最近一次完整扫描的结果为起点，变化通过 scan:item、scan:item-updated 和 scan:item-removed 事件推送
+func (a *App) StartWatch() error {
+	if a.watcher != nil {
+		return fmt.Errorf("already watching")
+	}
+	cfg := a.configManager.GetConfig()
+	if len(cfg.ScanPaths) == 0 {
+		return fmt.Errorf("no scan paths configured")
+	}
+
+	s := scanner.New(a.configManager.GetEnabledRules(), cfg.IgnorePatterns, cfg.GlobalPathExcludes)
+	s.SetSizeMode(cfg.SizeMode)
+	s.SetItemHandler(a.emitScanItem)
+
+	watcher, err := s.Watch(cfg.ScanPaths, a.lastResult)
+	if err != nil {
+		return fmt.Errorf("failed to watch scan paths: %w", err)
+	}
+	a.watcher = watcher
+	wailsRuntime.EventsEmit(a.ctx, "watch:status", true)
+	return nil
+}
+
+// StopWatch 停止监听
+func (a *App) StopWatch() {
+	if a.watcher == nil {
+		return
+	}
+	a.lastResult = a.watcher.Result()
+	a.watcher.Close()
+	a.watcher = nil
+	wailsRuntime.EventsEmit(a.ctx, "watch:status", false)
+}
+
+// IsWatching 是否正在监听
+func (a *App) IsWatching() bool {
+	return a.watcher != nil
+}
+
+// GetWatchResult 获取监听中的当前扫描结果，未监听时返回最近一次完整扫描的结果
+func (a *App) GetWatchResult() *models.ScanResult {
+	if a.watcher != nil {
+		return a.watcher.Result()
+	}
+	return a.lastResult
+}
+
+// ExplainPath 说明目录为什么会或不会被扫描为可清理项
+// 使用当前配置的全部规则（包括已禁用的）、忽略模式和扫描路径，执行与扫描相同的判断
+func (a *App) ExplainPath(path string) (*models.MatchExplanation, error) {
+	cfg := a.configManager.GetConfig()
+	s := scanner.New(cfg.ScanRules, cfg.IgnorePatterns, cfg.GlobalPathExcludes)
+	defer s.Close()
+
+	exp, err := s.Explain(path, cfg.ScanPaths)
+	if err != nil {
+		return nil, err
+	}
+	return &exp, nil
+}
+
+// emitScanItem 推送扫描项事件
+// scan:item 在找到目录时发送（大小待计算），scan:item-updated 在大小计算完成时发送，
+// scan:item-removed 在目录已不存在时发送
+func (a *App) emitScanItem(event models.ScanItemEvent) {
+	switch event.Kind {
+	case models.ScanItemFound:
+		wailsRuntime.EventsEmit(a.ctx, "scan:item", event.Item)
+	case models.ScanItemUpdated:
+		wailsRuntime.EventsEmit(a.ctx, "scan:item-updated", event.Item)
+	case models.ScanItemRemoved:
+		wailsRuntime.EventsEmit(a.ctx, "scan:item-removed", event.Item)
+	}
+}
+
 // listenScanProgress 监听扫描进度
 func (a *App) listenScanProgress() {
 	if a.currentScanner == nil {
@@ -111,22 +283,80 @@ func (a *App) CancelScan() {
 	}
 }
 
-// StartClean 开始清理
-func (a *App) StartClean(items []models.ScanItem) error {
+// StartClean 开始清理，使用配置中的删除方式，返回每个项目的清理结果
+func (a *App) StartClean(items []models.ScanItem) (*models.CleanResult, error) {
+	return a.StartCleanWithMode(items, a.configManager.GetConfig().DeleteMode)
+}
+
+// StartCleanWithMode 使用指定的删除方式开始清理（permanent、trash 或 quarantine）
+func (a *App) StartCleanWithMode(items []models.ScanItem, mode string) (*models.CleanResult, error) {
+	if mode == "" {
+		mode = models.DeleteModePermanent
+	}
+	cfg := a.configManager.GetConfig()
+	remover, err := cleaner.NewRemover(mode, cfg.SizeMode)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建清理器
-	a.currentCleaner = cleaner.New()
+	a.currentCleaner = a.newCleaner(remover, cfg)
 
 	// 启动进度监听
 	go a.listenCleanProgress()
 
 	// 执行清理
-	err := a.currentCleaner.Clean(items)
+	startTime := time.Now()
+	result, err := a.currentCleaner.Clean(items)
 
 	// 关闭清理器
 	a.currentCleaner.Close()
 	a.currentCleaner = nil
 
-	return err
+	// 记录清理历史
+	_ = history.Save(history.NewCleanRecord(result, mode, startTime, err))
+
+	return result, err
+}
+
+// PlanClean 模拟清理，不删除文件，返回清理计划
+// 与 StartClean 一样发送 clean:progress 事件
+func (a *App) PlanClean(items []models.ScanItem) (*models.CleanPlan, error) {
+	a.currentCleaner = a.newCleaner(nil, a.configManager.GetConfig())
+
+	// 启动进度监听
+	go a.listenCleanProgress()
+
+	plan, err := a.currentCleaner.DryRun(items)
+
+	// 关闭清理器
+	a.currentCleaner.Close()
+	a.currentCleaner = nil
+
+	return plan, err
+}
+
+// ListGlobalCaches 列出开发工具在项目之外的全局缓存（Maven、Gradle、npm 等）及其大小
+func (a *App) ListGlobalCaches() ([]models.GlobalCache, error) {
+	return globalcache.List(a.ctx, a.configManager.GetConfig().SizeMode)
+}
+
+// CleanGlobalCache 清理全局缓存，id 为 ListGlobalCaches 返回的标识
+// 默认只清理不再使用的条目（prune），opts.DryRun 为 true 时只计算将释放的空间
+func (a *App) CleanGlobalCache(id string, opts models.GlobalCacheCleanOptions) (*models.GlobalCacheCleanResult, error) {
+	cfg := a.configManager.GetConfig()
+	return globalcache.Clean(a.ctx, id, opts, cfg.SizeMode, cfg.ProtectedPaths)
+}
+
+// newCleaner 根据配置创建清理器
+// 前端传入的扫描项不能完全信任，只允许删除配置的扫描路径下仍然匹配规则的目录，
+// 扫描路径本身和用户定义的受保护路径永远不会被删除
+func (a *App) newCleaner(remover cleaner.Remover, cfg *models.Config) *cleaner.Cleaner {
+	cl := cleaner.New(remover, cfg.ScanPaths, cfg.ProtectedPaths)
+	cl.SetValidator(cleaner.NewValidator(cfg.ScanPaths, cfg.ScanRules))
+	cl.SetSizeMode(cfg.SizeMode)
+	cl.SetConcurrency(cfg.CleanConcurrency)
+	return cl
 }
 
 // listenCleanProgress 监听清理进度
@@ -147,6 +377,85 @@ func (a *App) CancelClean() {
 	}
 }
 
+// quarantineRetention 隔离区保留时长
+func (a *App) quarantineRetention() time.Duration {
+	return time.Duration(a.configManager.GetConfig().QuarantineDays) * 24 * time.Hour
+}
+
+// purgeExpiredQuarantine 永久删除超过保留期的隔离记录
+func (a *App) purgeExpiredQuarantine() {
+	store, err := quarantine.Open()
+	if err != nil {
+		return
+	}
+	_, _ = store.PurgeExpired(a.quarantineRetention())
+}
+
+// ListQuarantine 列出隔离区中的记录
+func (a *App) ListQuarantine() ([]models.QuarantineEntry, error) {
+	store, err := quarantine.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	retention := a.quarantineRetention()
+	for i := range entries {
+		entries[i].ExpiresAt = entries[i].QuarantinedAt.Add(retention)
+	}
+	return entries, nil
+}
+
+// RestoreQuarantined 将隔离的目录恢复到原始位置
+func (a *App) RestoreQuarantined(id string) error {
+	store, err := quarantine.Open()
+	if err != nil {
+		return err
+	}
+	return store.Restore(id)
+}
+
+// PurgeQuarantine 永久删除隔离记录，id 为空时清空整个隔离区
+func (a *App) PurgeQuarantine(id string) error {
+	store, err := quarantine.Open()
+	if err != nil {
+		return err
+	}
+	return store.Purge(id)
+}
+
+// ListHistory 查询扫描和清理历史
+func (a *App) ListHistory(filter models.HistoryFilter) ([]models.HistoryRecord, error) {
+	store, err := history.Open()
+	if err != nil {
+		return nil, err
+	}
+	return store.List(filter)
+}
+
+// DeleteHistory 删除指定的历史记录
+func (a *App) DeleteHistory(ids []string) error {
+	store, err := history.Open()
+	if err != nil {
+		return err
+	}
+	_, err = store.Delete(ids)
+	return err
+}
+
+// ClearHistory 清空所有历史记录
+func (a *App) ClearHistory() error {
+	store, err := history.Open()
+	if err != nil {
+		return err
+	}
+	return store.Clear()
+}
+
 // SelectDirectory 选择目录
 func (a *App) SelectDirectory() (string, error) {
 	return wailsRuntime.OpenDirectoryDialog(a.ctx, wailsRuntime.OpenDialogOptions{
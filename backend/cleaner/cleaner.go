@@ -2,86 +2,376 @@ package cleaner
 
 import (
 	"context"
+	"errors"
 	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
 	"os"
 	"sync"
+	"time"
 )
 
 // Cleaner 清理器
 type Cleaner struct {
+	remover      Remover
+	protector    *Protector
+	validator    *Validator
+	sizeMode     string
+	concurrency  int
 	progressChan chan models.CleanProgress
+	lastProgress models.CleanProgress
 	mu           sync.Mutex
 	ctx          context.Context
 	cancel       context.CancelFunc
 }
 
-// New 创建新的清理器
-func New() *Cleaner {
+// New 创建新的清理器，remover 为 nil 时使用永久删除
+// 清理器总是启用受保护路径策略，scanPaths 和 protectedPaths 见 NewProtector
+func New(remover Remover, scanPaths, protectedPaths []string) *Cleaner {
+	if remover == nil {
+		remover = PermanentRemover{}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Cleaner{
+		remover:      remover,
+		protector:    NewProtector(scanPaths, protectedPaths),
 		progressChan: make(chan models.CleanProgress, 100),
 		ctx:          ctx,
 		cancel:       cancel,
 	}
 }
 
-// Clean 清理指定的项目
-func (c *Cleaner) Clean(items []models.ScanItem) error {
-	totalCount := len(items)
-	cleanedCount := 0
-	var cleanedSize int64
-	failedItems := make([]string, 0)
+// SetConcurrency 设置同时清理的项目数，小于等于 0 时根据磁盘类型自动选择
+func (c *Cleaner) SetConcurrency(n int) {
+	c.concurrency = n
+}
+
+// SetSizeMode 设置模拟清理和失败详情中大小的统计方式，见 models.SizeMode* 常量
+// 实际释放的大小由删除策略统计，见 NewRemover
+func (c *Cleaner) SetSizeMode(mode string) {
+	c.sizeMode = mode
+}
+
+// SetValidator 设置删除前的校验器，校验未通过的项目会被拒绝而不是删除
+func (c *Cleaner) SetValidator(v *Validator) {
+	c.validator = v
+}
+
+// Clean 清理指定的项目，返回每个项目的清理结果
+// 取消时返回已处理部分的结果和 context.Canceled
+func (c *Cleaner) Clean(items []models.ScanItem) (*models.CleanResult, error) {
+	result, _, err := c.run(items, false)
+	return result, err
+}
+
+// DryRun 模拟清理：重新确认选中的项目是否存在并重新计算大小，
+// 发送与 Clean 相同的进度事件，但不删除任何文件，返回清理计划
+func (c *Cleaner) DryRun(items []models.ScanItem) (*models.CleanPlan, error) {
+	_, plan, err := c.run(items, true)
+	return plan, err
+}
 
+// run 使用工作池并发处理选中的项目，dryRun 为 true 时只生成计划不删除
+func (c *Cleaner) run(items []models.ScanItem, dryRun bool) (*models.CleanResult, *models.CleanPlan, error) {
+	startTime := time.Now()
+
+	result := &models.CleanResult{
+		Items: make([]models.CleanItemResult, len(items)),
+	}
+	planItems := make([]*models.CleanPlanItem, len(items))
+
+	// 未选中的项目直接跳过
+	selected := make([]int, 0, len(items))
 	for i, item := range items {
-		// 检查是否取消
-		select {
-		case <-c.ctx.Done():
-			return context.Canceled
-		default:
+		result.Items[i] = models.CleanItemResult{
+			Path:        item.Path,
+			ProjectName: item.ProjectName,
+			Type:        item.Type,
+		}
+		if item.Selected {
+			selected = append(selected, i)
+		} else {
+			result.Items[i].Status = models.CleanStatusSkipped
+			result.Items[i].Error = "not selected"
 		}
+	}
 
-		// 只清理选中的项目
-		if !item.Selected {
-			continue
+	concurrency := c.concurrency
+	if concurrency <= 0 {
+		paths := make([]string, 0, len(selected))
+		for _, i := range selected {
+			paths = append(paths, items[i].Path)
 		}
+		concurrency = DefaultConcurrency(paths)
+	}
 
-		// 发送进度更新
-		c.sendProgress(models.CleanProgress{
-			CurrentPath:  item.Path,
-			CleanedCount: cleanedCount,
-			TotalCount:   totalCount,
-			CleanedSize:  cleanedSize,
-			IsCleaning:   true,
-			Progress:     (i * 100) / totalCount,
-			FailedItems:  failedItems,
-		})
-
-		// 删除目录
-		err := os.RemoveAll(item.Path)
-		if err != nil {
-			failedItems = append(failedItems, item.Path)
-		} else {
-			cleanedCount++
-			cleanedSize += item.Size
+	// 发送初始进度
+	progress := models.CleanProgress{
+		TotalCount:  len(selected),
+		IsCleaning:  true,
+		FailedItems: make([]string, 0),
+		Failures:    make([]models.CleanFailure, 0),
+		Rejections:  make([]models.CleanRejection, 0),
+		DryRun:      dryRun,
+	}
+	c.sendProgress(progress)
+
+	jobs := make(chan int)
+	done := make(chan int)
+
+	// 分发任务，取消后不再分发新任务
+	go func() {
+		defer close(jobs)
+		for _, i := range selected {
+			select {
+			case <-c.ctx.Done():
+				return
+			case jobs <- i:
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// 取消后不再处理已分发的任务
+				if c.ctx.Err() != nil {
+					continue
+				}
+				if dryRun {
+					planItem := c.checkItem(items[i])
+					planItems[i] = &planItem
+					result.Items[i] = planResult(result.Items[i], planItem)
+				} else {
+					result.Items[i] = c.cleanItem(result.Items[i], items[i])
+				}
+				done <- i
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// 在单个 goroutine 中汇总结果，保证进度按完成顺序递增
+	completed := 0
+	for i := range done {
+		completed++
+		itemResult := result.Items[i]
+
+		// 失败的项目可能已经删除了一部分，同样计入释放或移走的大小
+		progress.CleanedSize += itemResult.FreedSize
+		progress.MovedSize += itemResult.MovedSize
+
+		switch itemResult.Status {
+		case models.CleanStatusSuccess:
+			progress.CleanedCount++
+		case models.CleanStatusFailed:
+			progress.FailedItems = append(progress.FailedItems, itemResult.Path)
+			if itemResult.Failure != nil {
+				progress.Failures = append(progress.Failures, *itemResult.Failure)
+			}
+		case models.CleanStatusRejected:
+			progress.Rejections = append(progress.Rejections, models.CleanRejection{
+				Path:   itemResult.Path,
+				Reason: itemResult.Error,
+			})
+		}
+
+		progress.CurrentPath = itemResult.Path
+		progress.Progress = completed * 100 / len(selected)
+		progress.LastResult = &itemResult
+		c.sendProgress(progress)
+	}
+
+	// 取消后未处理的项目
+	for _, i := range selected {
+		if result.Items[i].Status == "" {
+			result.Items[i].Status = models.CleanStatusCancelled
+			result.Cancelled = true
+		}
+	}
+
+	var cleanErr error
+	if result.Cancelled {
+		cleanErr = context.Canceled
+	}
+
+	for _, itemResult := range result.Items {
+		result.CleanedSize += itemResult.FreedSize
+		result.MovedSize += itemResult.MovedSize
+
+		switch itemResult.Status {
+		case models.CleanStatusSuccess:
+			result.CleanedCount++
+		case models.CleanStatusFailed:
+			result.FailedCount++
+		case models.CleanStatusSkipped:
+			result.SkippedCount++
+		case models.CleanStatusCancelled:
+			result.CancelledCount++
+		case models.CleanStatusRejected:
+			result.RejectedCount++
+		}
+	}
+	result.Duration = time.Since(startTime).Milliseconds()
+
+	// 发送最终进度（取消时也发送，以便报告已清理的部分）
+	progress.CurrentPath = ""
+	progress.IsCleaning = false
+	progress.Progress = 100
+	progress.LastResult = nil
+	c.sendProgress(progress)
+
+	plan := &models.CleanPlan{
+		Items:     make([]models.CleanPlanItem, 0, len(selected)),
+		CreatedAt: startTime,
+	}
+	for _, planItem := range planItems {
+		if planItem == nil {
+			continue
 		}
+		plan.Items = append(plan.Items, *planItem)
+		switch planItem.Action {
+		case models.PlanActionDelete:
+			plan.TotalCount++
+			plan.TotalSize += planItem.CurrentSize
+		case models.PlanActionReject:
+			plan.RejectedCount++
+		default:
+			plan.SkippedCount++
+		}
+	}
+
+	return result, plan, cleanErr
+}
+
+// cleanItem 删除单个项目
+func (c *Cleaner) cleanItem(itemResult models.CleanItemResult, item models.ScanItem) models.CleanItemResult {
+	// 受保护路径总是最先检查，不受路径是否存在和是否设置校验器影响
+	if err := c.protector.Check(item.Path); err != nil {
+		itemResult.Status = models.CleanStatusRejected
+		itemResult.Error = err.Error()
+		return itemResult
+	}
+
+	if _, err := os.Lstat(item.Path); errors.Is(err, os.ErrNotExist) {
+		itemResult.Status = models.CleanStatusSkipped
+		itemResult.Error = "path no longer exists"
+		return itemResult
+	}
+
+	if err := c.validate(item); err != nil {
+		itemResult.Status = models.CleanStatusRejected
+		itemResult.Error = err.Error()
+		return itemResult
+	}
+
+	// 使用删除策略统计的实际大小，失败时也计入已删除的部分
+	// 移到回收站或隔离区的内容仍然占用空间，不计入释放的大小
+	removed, err := c.remover.Remove(item)
+	if keeper, ok := c.remover.(Keeper); ok && keeper.KeepsRemoved() {
+		itemResult.MovedSize = removed
+	} else {
+		itemResult.FreedSize = removed
+	}
+	if err != nil {
+		itemResult.Status = models.CleanStatusFailed
+		itemResult.Error = err.Error()
+		itemResult.Failure = newFailure(item.Path, removed, err, c.sizeMode)
+		return itemResult
+	}
+
+	itemResult.Status = models.CleanStatusSuccess
+	return itemResult
+}
+
+// validate 使用校验器重新校验扫描项，未设置校验器时不校验
+func (c *Cleaner) validate(item models.ScanItem) error {
+	if c.validator == nil {
+		return nil
+	}
+	return c.validator.Validate(item)
+}
+
+// planResult 将清理计划中的一项转换为清理结果
+func planResult(itemResult models.CleanItemResult, planItem models.CleanPlanItem) models.CleanItemResult {
+	switch planItem.Action {
+	case models.PlanActionDelete:
+		itemResult.Status = models.CleanStatusSuccess
+		itemResult.FreedSize = planItem.CurrentSize
+	case models.PlanActionReject:
+		itemResult.Status = models.CleanStatusRejected
+		itemResult.Error = planItem.Reason
+	default:
+		itemResult.Status = models.CleanStatusSkipped
+		itemResult.Error = planItem.Reason
 	}
+	return itemResult
+}
 
-	// 发送最终进度
-	c.sendProgress(models.CleanProgress{
-		CurrentPath:  "",
-		CleanedCount: cleanedCount,
-		TotalCount:   totalCount,
-		CleanedSize:  cleanedSize,
-		IsCleaning:   false,
-		Progress:     100,
-		FailedItems:  failedItems,
-	})
+// checkItem 重新检查扫描项，生成清理计划中的一项
+func (c *Cleaner) checkItem(item models.ScanItem) models.CleanPlanItem {
+	planItem := models.CleanPlanItem{
+		Path:        item.Path,
+		ProjectName: item.ProjectName,
+		Type:        item.Type,
+		ScannedSize: item.Size,
+		Action:      models.PlanActionSkip,
+	}
+
+	if err := c.protector.Check(item.Path); err != nil {
+		planItem.Action = models.PlanActionReject
+		planItem.Reason = err.Error()
+		return planItem
+	}
+
+	info, err := os.Lstat(item.Path)
+	if err != nil {
+		planItem.Reason = err.Error()
+		return planItem
+	}
+	planItem.Exists = true
 
-	return nil
+	if err := c.validate(item); err != nil {
+		planItem.Action = models.PlanActionReject
+		planItem.Reason = err.Error()
+		return planItem
+	}
+
+	if !info.IsDir() {
+		planItem.Reason = "not a directory"
+		return planItem
+	}
+
+	usage, err := utils.MeasureDir(item.Path)
+	if err != nil {
+		planItem.Reason = err.Error()
+		return planItem
+	}
+
+	planItem.CurrentSize = usage.Size(c.sizeMode)
+	planItem.SizeReadable = utils.FormatSize(planItem.CurrentSize)
+	planItem.FileCount = usage.FileCount
+	planItem.Action = models.PlanActionDelete
+	return planItem
 }
 
 // sendProgress 发送进度更新
 func (c *Cleaner) sendProgress(progress models.CleanProgress) {
+	// 复制失败列表，避免后续追加影响已发送的进度
+	progress.FailedItems = append(make([]string, 0, len(progress.FailedItems)), progress.FailedItems...)
+	progress.Failures = append(make([]models.CleanFailure, 0, len(progress.Failures)), progress.Failures...)
+
+	c.mu.Lock()
+	c.lastProgress = progress
+	c.mu.Unlock()
+
 	select {
 	case c.progressChan <- progress:
 	default:
@@ -89,6 +379,13 @@ func (c *Cleaner) sendProgress(progress models.CleanProgress) {
 	}
 }
 
+// Progress 获取最近一次的进度，清理结束后即为最终结果
+func (c *Cleaner) Progress() models.CleanProgress {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastProgress
+}
+
 // GetProgressChan 获取进度通道
 func (c *Cleaner) GetProgressChan() <-chan models.CleanProgress {
 	return c.progressChan
@@ -0,0 +1,249 @@
+package cleaner
+
+import (
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newDir 创建包含指定大小文件的目录
+func newDir(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "file"), make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// collectProgress 在后台收集进度事件
+func collectProgress(c *Cleaner) <-chan []models.CleanProgress {
+	result := make(chan []models.CleanProgress, 1)
+	go func() {
+		var events []models.CleanProgress
+		for progress := range c.GetProgressChan() {
+			events = append(events, progress)
+		}
+		result <- events
+	}()
+	return result
+}
+
+func TestDryRun(t *testing.T) {
+	root := t.TempDir()
+	grown := filepath.Join(root, "a", "node_modules")
+	missing := filepath.Join(root, "b", "target")
+	unselected := filepath.Join(root, "c", "dist")
+	newDir(t, grown, 300)
+	newDir(t, unselected, 10)
+
+	items := []models.ScanItem{
+		{Path: grown, Type: "Node.js", Size: 100, Selected: true},
+		{Path: missing, Type: "Maven", Size: 50, Selected: true},
+		{Path: unselected, Type: "Node.js", Size: 10, Selected: false},
+	}
+
+	c := New(nil, nil, nil)
+	events := collectProgress(c)
+	plan, err := c.DryRun(items)
+	c.Close()
+	if err != nil {
+		t.Fatalf("DryRun() error: %v", err)
+	}
+
+	// 不删除任何文件
+	for _, path := range []string{grown, unselected} {
+		if !utils.PathExists(path) {
+			t.Errorf("DryRun() removed %s", path)
+		}
+	}
+
+	if len(plan.Items) != 2 {
+		t.Fatalf("plan has %d items, want 2 (unselected items excluded)", len(plan.Items))
+	}
+	if item := plan.Items[0]; item.Action != models.PlanActionDelete || item.CurrentSize != 300 || item.ScannedSize != 100 {
+		t.Errorf("plan item for grown dir = %+v, want delete with re-measured size 300", item)
+	}
+	if item := plan.Items[1]; item.Action != models.PlanActionSkip || item.Exists || item.Reason == "" {
+		t.Errorf("plan item for missing dir = %+v, want skip with reason", item)
+	}
+	if plan.TotalCount != 1 || plan.TotalSize != 300 || plan.SkippedCount != 1 {
+		t.Errorf("plan totals = %d/%d/%d, want 1/300/1", plan.TotalCount, plan.TotalSize, plan.SkippedCount)
+	}
+
+	// 初始进度、每个选中项目完成时各一次、最终进度
+	progress := <-events
+	if len(progress) != 4 {
+		t.Fatalf("got %d progress events, want 4", len(progress))
+	}
+	final := progress[len(progress)-1]
+	if !final.DryRun || final.IsCleaning || final.CleanedSize != 300 || final.TotalCount != 2 {
+		t.Errorf("final progress = %+v", final)
+	}
+}
+
+func TestClean(t *testing.T) {
+	root := t.TempDir()
+	selected := filepath.Join(root, "a", "target")
+	unselected := filepath.Join(root, "b", "target")
+	newDir(t, selected, 10)
+	newDir(t, unselected, 10)
+
+	c := New(PermanentRemover{}, nil, nil)
+	events := collectProgress(c)
+	result, err := c.Clean([]models.ScanItem{
+		{Path: selected, Size: 10, Selected: true},
+		{Path: unselected, Size: 10, Selected: false},
+		{Path: filepath.Join(root, "missing"), Size: 10, Selected: true},
+	})
+	c.Close()
+	<-events
+	if err != nil {
+		t.Fatalf("Clean() error: %v", err)
+	}
+
+	if utils.PathExists(selected) {
+		t.Error("selected item was not removed")
+	}
+	if !utils.PathExists(unselected) {
+		t.Error("unselected item was removed")
+	}
+
+	expected := []string{models.CleanStatusSuccess, models.CleanStatusSkipped, models.CleanStatusSkipped}
+	for i, status := range expected {
+		if result.Items[i].Status != status {
+			t.Errorf("item %d status = %s, want %s", i, result.Items[i].Status, status)
+		}
+	}
+	if result.CleanedCount != 1 || result.CleanedSize != 10 || result.SkippedCount != 2 {
+		t.Errorf("result = %+v, want 1 cleaned, 10 bytes, 2 skipped", result)
+	}
+	if progress := c.Progress(); progress.CleanedCount != 1 || progress.CleanedSize != 10 {
+		t.Errorf("Progress() = %+v, want 1 item and 10 bytes", progress)
+	}
+}
+
+// keepingRemover 模拟回收站和隔离区：从原位置移除，但不释放空间
+type keepingRemover struct {
+	PermanentRemover
+}
+
+func (keepingRemover) KeepsRemoved() bool {
+	return true
+}
+
+func TestCleanReportsMovedSize(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "a", "target")
+	newDir(t, target, 10)
+
+	c := New(keepingRemover{}, nil, nil)
+	events := collectProgress(c)
+	result, err := c.Clean([]models.ScanItem{{Path: target, Size: 10, Selected: true}})
+	c.Close()
+	<-events
+	if err != nil {
+		t.Fatalf("Clean() error: %v", err)
+	}
+
+	if result.CleanedCount != 1 || result.CleanedSize != 0 || result.MovedSize != 10 {
+		t.Errorf("result = %+v, want 1 cleaned, 0 bytes freed, 10 bytes moved", result)
+	}
+	if item := result.Items[0]; item.FreedSize != 0 || item.MovedSize != 10 {
+		t.Errorf("item = %+v, want 10 bytes moved", item)
+	}
+	if progress := c.Progress(); progress.CleanedSize != 0 || progress.MovedSize != 10 {
+		t.Errorf("Progress() = %+v, want 10 bytes moved", progress)
+	}
+}
+
+// blockingRemover 在删除时阻塞，用于测试并发和取消
+type blockingRemover struct {
+	started chan string
+	release chan struct{}
+}
+
+func (r *blockingRemover) Remove(item models.ScanItem) (int64, error) {
+	r.started <- item.Path
+	<-r.release
+	return item.Size, nil
+}
+
+func TestCleanConcurrencyAndCancel(t *testing.T) {
+	root := t.TempDir()
+	items := make([]models.ScanItem, 10)
+	for i := range items {
+		items[i] = models.ScanItem{Path: filepath.Join(root, string(rune('a'+i))), Size: 1, Selected: true}
+		newDir(t, items[i].Path, 1)
+	}
+
+	remover := &blockingRemover{started: make(chan string, len(items)), release: make(chan struct{})}
+	c := New(remover, nil, nil)
+	c.SetConcurrency(3)
+	events := collectProgress(c)
+
+	type cleanReturn struct {
+		result *models.CleanResult
+		err    error
+	}
+	returned := make(chan cleanReturn, 1)
+	go func() {
+		result, err := c.Clean(items)
+		returned <- cleanReturn{result, err}
+	}()
+
+	// 3 个工作协程同时开始删除
+	for i := 0; i < 3; i++ {
+		<-remover.started
+	}
+	c.Cancel()
+	close(remover.release)
+
+	ret := <-returned
+	c.Close()
+	<-events
+
+	if ret.err == nil {
+		t.Fatal("Clean() should return an error after cancel")
+	}
+	// 已开始的项目完成删除，其余项目被取消
+	if ret.result.CleanedCount < 3 || ret.result.CancelledCount == 0 ||
+		ret.result.CleanedCount+ret.result.CancelledCount != len(items) {
+		t.Errorf("result = %+v, want >= 3 cleaned and the rest cancelled", ret.result)
+	}
+	if !ret.result.Cancelled {
+		t.Error("result.Cancelled should be true")
+	}
+}
+
+func TestRemoveAll(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "node_modules")
+	newDir(t, filepath.Join(target, "a"), 100)
+	newDir(t, filepath.Join(target, "a", "b"), 50)
+	if err := os.Symlink(filepath.Join(target, "a"), filepath.Join(target, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, _ := utils.MeasureDir(target)
+	expected := usage.ApparentSize
+
+	freed, err := removeAll(target, models.SizeModeApparent)
+	if err != nil {
+		t.Fatalf("removeAll() error: %v", err)
+	}
+	// 与扫描时的统计方式一致，符号链接不跟随
+	if freed != expected {
+		t.Errorf("removeAll() freed %d bytes, want %d", freed, expected)
+	}
+	if utils.PathExists(target) {
+		t.Error("target still exists after removeAll()")
+	}
+
+	if freed, err := removeAll(target, models.SizeModeApparent); err != nil || freed != 0 {
+		t.Errorf("removeAll() on missing path = %d, %v; want 0, nil", freed, err)
+	}
+}
@@ -0,0 +1,28 @@
+package cleaner
+
+import "runtime"
+
+// 清理并发数
+const (
+	rotationalConcurrency = 2  // 机械硬盘：并发过高会导致磁头频繁寻道
+	maxSSDConcurrency     = 16 // 固态硬盘：删除主要受元数据操作限制
+)
+
+// DefaultConcurrency 根据待清理路径所在磁盘类型选择并发数
+// 任一路径位于机械硬盘时使用较低的并发数
+func DefaultConcurrency(paths []string) int {
+	for _, path := range paths {
+		if rotational, ok := isRotational(path); ok && rotational {
+			return rotationalConcurrency
+		}
+	}
+
+	n := runtime.NumCPU() * 2
+	if n > maxSSDConcurrency {
+		n = maxSSDConcurrency
+	}
+	if n < rotationalConcurrency {
+		n = rotationalConcurrency
+	}
+	return n
+}
@@ -0,0 +1,40 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// isRotational 通过 /sys/dev/block 判断路径所在磁盘是否为机械硬盘
+// 第二个返回值表示是否能够判断
+func isRotational(path string) (bool, bool) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		// 路径可能已被删除，使用父目录判断
+		parent := filepath.Dir(path)
+		if parent == path || syscall.Stat(parent, &stat) != nil {
+			return false, false
+		}
+	}
+
+	dev := uint64(stat.Dev)
+	major := ((dev >> 8) & 0xfff) | ((dev >> 32) &^ 0xfff)
+	minor := (dev & 0xff) | ((dev >> 12) &^ 0xff)
+
+	blockDir, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", major, minor))
+	if err != nil {
+		return false, false
+	}
+
+	// 分区目录位于磁盘目录下，queue 只存在于磁盘目录
+	for _, dir := range []string{blockDir, filepath.Dir(blockDir)} {
+		data, err := os.ReadFile(filepath.Join(dir, "queue", "rotational"))
+		if err == nil {
+			return strings.TrimSpace(string(data)) == "1", true
+		}
+	}
+	return false, false
+}
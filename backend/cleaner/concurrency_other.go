@@ -0,0 +1,8 @@
+//go:build !linux
+
+package cleaner
+
+// isRotational 当前平台无法判断磁盘类型，按固态硬盘处理
+func isRotational(path string) (bool, bool) {
+	return false, false
+}
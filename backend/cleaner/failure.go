@@ -0,0 +1,68 @@
+package cleaner
+
+import (
+	"errors"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"io/fs"
+	"os"
+)
+
+// 各类错误的建议解决方法
+var failureSuggestions = map[string]string{
+	models.FailureKindPermission:  "权限不足，请检查文件权限或以管理员权限重试",
+	models.FailureKindBusy:        "文件被其他程序占用，请关闭相关程序（如 IDE、构建进程、开发服务器）后重试",
+	models.FailureKindReadOnly:    "文件位于只读文件系统，请重新挂载为可写后重试",
+	models.FailureKindNotFound:    "路径已不存在，请重新扫描",
+	models.FailureKindUnsupported: "当前删除方式在此位置不可用，请更换删除方式后重试",
+	models.FailureKindUnknown:     "请查看错误信息后重试",
+}
+
+// classifyError 判断删除错误的类型
+func classifyError(err error) string {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return models.FailureKindNotFound
+	case isBusyError(err):
+		return models.FailureKindBusy
+	case isReadOnlyError(err):
+		return models.FailureKindReadOnly
+	case errors.Is(err, fs.ErrPermission):
+		return models.FailureKindPermission
+	case isUnsupportedError(err):
+		return models.FailureKindUnsupported
+	default:
+		return models.FailureKindUnknown
+	}
+}
+
+// newFailure 根据删除错误生成失败详情，并统计目录中剩余的内容
+// removedSize 为删除策略报告的失败前已删除的大小，sizeMode 为剩余大小的统计方式
+func newFailure(path string, removedSize int64, err error, sizeMode string) *models.CleanFailure {
+	kind := classifyError(err)
+	failure := &models.CleanFailure{
+		Path:        path,
+		Kind:        kind,
+		Error:       err.Error(),
+		FailedPath:  path,
+		RemovedSize: removedSize,
+		Suggestion:  failureSuggestions[kind],
+	}
+
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	if errors.As(err, &pathErr) {
+		failure.FailedPath = pathErr.Path
+		failure.Error = pathErr.Err.Error()
+	} else if errors.As(err, &linkErr) {
+		failure.FailedPath = linkErr.Old
+		failure.Error = linkErr.Err.Error()
+	}
+
+	if utils.PathExists(path) {
+		usage, _ := utils.MeasureDir(path)
+		failure.RemainingSize = usage.Size(sizeMode)
+		failure.RemainingFiles = usage.FileCount
+	}
+	return failure
+}
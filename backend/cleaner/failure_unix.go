@@ -0,0 +1,23 @@
+//go:build !windows
+
+package cleaner
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isBusyError 判断是否为文件被占用错误
+func isBusyError(err error) bool {
+	return errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.ETXTBSY)
+}
+
+// isReadOnlyError 判断是否为只读文件系统错误
+func isReadOnlyError(err error) bool {
+	return errors.Is(err, syscall.EROFS)
+}
+
+// isUnsupportedError 判断是否为删除方式不可用（如跨文件系统移动）
+func isUnsupportedError(err error) bool {
+	return errors.Is(err, syscall.EXDEV) || errors.Is(err, errors.ErrUnsupported)
+}
@@ -0,0 +1,100 @@
+//go:build !windows
+
+package cleaner
+
+import (
+	"errors"
+	"fast-clean-x/backend/models"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&fs.PathError{Op: "unlinkat", Path: "/p/a", Err: syscall.EACCES}, models.FailureKindPermission},
+		{&fs.PathError{Op: "unlinkat", Path: "/p/a", Err: syscall.EPERM}, models.FailureKindPermission},
+		{&fs.PathError{Op: "unlinkat", Path: "/p/a", Err: syscall.EBUSY}, models.FailureKindBusy},
+		{&fs.PathError{Op: "unlinkat", Path: "/p/a", Err: syscall.ETXTBSY}, models.FailureKindBusy},
+		{&fs.PathError{Op: "unlinkat", Path: "/p/a", Err: syscall.EROFS}, models.FailureKindReadOnly},
+		{&fs.PathError{Op: "lstat", Path: "/p/a", Err: syscall.ENOENT}, models.FailureKindNotFound},
+		{&os.LinkError{Op: "rename", Old: "/p/a", New: "/t/a", Err: syscall.EXDEV}, models.FailureKindUnsupported},
+		{fmt.Errorf("move to trash: %w", errors.ErrUnsupported), models.FailureKindUnsupported},
+		{errors.New("boom"), models.FailureKindUnknown},
+	}
+
+	for _, tt := range tests {
+		if kind := classifyError(tt.err); kind != tt.expected {
+			t.Errorf("classifyError(%v) = %s, want %s", tt.err, kind, tt.expected)
+		}
+	}
+}
+
+// failingRemover 删除部分文件后返回错误，模拟 os.RemoveAll 中途失败
+type failingRemover struct {
+	keep string
+}
+
+func (r failingRemover) Remove(item models.ScanItem) (int64, error) {
+	entries, err := os.ReadDir(item.Path)
+	if err != nil {
+		return 0, err
+	}
+	var freed int64
+	for _, entry := range entries {
+		if entry.Name() != r.keep {
+			n, _ := removeAll(filepath.Join(item.Path, entry.Name()), models.SizeModeApparent)
+			freed += n
+		}
+	}
+	return freed, &fs.PathError{Op: "unlinkat", Path: filepath.Join(item.Path, r.keep), Err: syscall.EBUSY}
+}
+
+func TestCleanFailureDetails(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "node_modules")
+	newDir(t, filepath.Join(target, "a"), 300)
+	newDir(t, filepath.Join(target, "b"), 100)
+
+	c := New(failingRemover{keep: "b"}, nil, nil)
+	events := collectProgress(c)
+	result, err := c.Clean([]models.ScanItem{{Path: target, Size: 1000, Selected: true}})
+	c.Close()
+	progress := <-events
+	if err != nil {
+		t.Fatalf("Clean() error: %v", err)
+	}
+
+	failure := result.Items[0].Failure
+	if result.Items[0].Status != models.CleanStatusFailed || failure == nil {
+		t.Fatalf("item result = %+v, want failed with details", result.Items[0])
+	}
+	expected := models.CleanFailure{
+		Path:           target,
+		Kind:           models.FailureKindBusy,
+		Error:          syscall.EBUSY.Error(),
+		FailedPath:     filepath.Join(target, "b"),
+		RemovedSize:    300,
+		RemainingSize:  100,
+		RemainingFiles: 1,
+		Suggestion:     failureSuggestions[models.FailureKindBusy],
+	}
+	if *failure != expected {
+		t.Errorf("failure = %+v, want %+v", *failure, expected)
+	}
+
+	final := progress[len(progress)-1]
+	if len(final.Failures) != 1 || final.Failures[0] != expected {
+		t.Errorf("final progress failures = %+v", final.Failures)
+	}
+
+	// 扫描时的大小已过期，释放的大小以实际删除为准
+	if result.Items[0].FreedSize != 300 || result.CleanedSize != 300 || final.CleanedSize != 300 {
+		t.Errorf("freed = %d/%d/%d, want 300", result.Items[0].FreedSize, result.CleanedSize, final.CleanedSize)
+	}
+}
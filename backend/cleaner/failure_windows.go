@@ -0,0 +1,30 @@
+package cleaner
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Windows 错误码
+const (
+	errorSharingViolation syscall.Errno = 32 // ERROR_SHARING_VIOLATION
+	errorLockViolation    syscall.Errno = 33 // ERROR_LOCK_VIOLATION
+	errorNotSameDevice    syscall.Errno = 17 // ERROR_NOT_SAME_DEVICE
+	errorWriteProtect     syscall.Errno = 19 // ERROR_WRITE_PROTECT
+)
+
+// isBusyError 判断是否为文件被占用错误
+// ERROR_DIR_NOT_EMPTY 通常是删除过程中目录里又出现了新文件，不一定被占用，按未知错误处理
+func isBusyError(err error) bool {
+	return errors.Is(err, errorSharingViolation) || errors.Is(err, errorLockViolation)
+}
+
+// isReadOnlyError 判断是否为只读介质错误
+func isReadOnlyError(err error) bool {
+	return errors.Is(err, errorWriteProtect)
+}
+
+// isUnsupportedError 判断是否为删除方式不可用（如跨卷移动）
+func isUnsupportedError(err error) bool {
+	return errors.Is(err, errorNotSameDevice) || errors.Is(err, errors.ErrUnsupported)
+}
@@ -0,0 +1,145 @@
+package cleaner
+
+import (
+	"errors"
+	"fast-clean-x/backend/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrProtectedPath 路径受保护，不允许删除
+var ErrProtectedPath = errors.New("protected path")
+
+// Protector 受保护路径策略，受保护的路径无论删除方式和校验设置如何都不会被删除
+//
+// 保护范围：
+//   - 文件系统根目录
+//   - 位于系统目录（utils.ShouldSkipDir）中的路径
+//   - 主目录、扫描根目录：本身及其上级目录
+//   - 配置目录、用户定义的受保护路径：本身、上级目录及其中的所有内容
+type Protector struct {
+	paths    []string // 本身及其上级目录受保护
+	subtrees []string // 本身、上级目录及其中的所有内容受保护
+}
+
+// NewProtector 创建受保护路径策略，总是保护主目录和配置目录，
+// 另外保护调用方传入的扫描路径和用户定义的受保护路径
+func NewProtector(scanPaths, protectedPaths []string) *Protector {
+	p := &Protector{}
+
+	if home, err := utils.GetHomeDir(); err == nil {
+		p.Protect(home)
+		p.ProtectSubtree(filepath.Join(home, utils.ConfigDirName))
+	}
+
+	p.Protect(scanPaths...)
+	p.ProtectSubtree(protectedPaths...)
+	return p
+}
+
+// Protect 保护路径本身及其上级目录，路径中的内容仍然可以删除
+func (p *Protector) Protect(paths ...string) {
+	p.paths = append(p.paths, protectedForms(paths)...)
+}
+
+// ProtectSubtree 保护路径本身、上级目录及其中的所有内容
+func (p *Protector) ProtectSubtree(paths ...string) {
+	p.subtrees = append(p.subtrees, protectedForms(paths)...)
+}
+
+// Check 检查路径是否允许删除，受保护时返回包装了 ErrProtectedPath 的错误
+// 同时检查原始路径和解析符号链接后的路径，防止通过 ..、重复分隔符、大小写或符号链接绕过
+func (p *Protector) Check(path string) error {
+	if path == "" || !filepath.IsAbs(path) {
+		return fmt.Errorf("%w: path is not absolute: %q", ErrProtectedPath, path)
+	}
+
+	for _, candidate := range resolvedForms(path) {
+		if err := p.check(candidate); err != nil {
+			return fmt.Errorf("%w: %s %s", ErrProtectedPath, path, err.Error())
+		}
+	}
+	return nil
+}
+
+// check 检查单个规范化后的路径
+func (p *Protector) check(path string) error {
+	if isFilesystemRoot(path) {
+		return errors.New("is a filesystem root")
+	}
+
+	for _, component := range strings.Split(path, string(filepath.Separator)) {
+		if component != "" && utils.ShouldSkipDir(component) {
+			return fmt.Errorf("is inside system directory %q", component)
+		}
+	}
+
+	for _, protected := range p.paths {
+		if path == protected || utils.IsUnder(protected, path) {
+			return fmt.Errorf("contains protected path %s", protected)
+		}
+	}
+	for _, protected := range p.subtrees {
+		if path == protected || utils.IsUnder(protected, path) || utils.IsUnder(path, protected) {
+			return fmt.Errorf("overlaps protected path %s", protected)
+		}
+	}
+	return nil
+}
+
+// protectedForms 返回受保护路径的规范化形式，包括解析符号链接后的路径
+func protectedForms(paths []string) []string {
+	forms := make([]string, 0, len(paths)*2)
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		forms = append(forms, resolvedForms(absPath)...)
+	}
+	return forms
+}
+
+// resolvedForms 返回路径的规范化形式：清理后的原始路径，以及解析符号链接后的路径
+// 路径不存在时只解析上级目录
+func resolvedForms(path string) []string {
+	path = filepath.Clean(path)
+	forms := []string{normalizePath(path)}
+
+	realPath, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if realParent, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+			realPath = filepath.Join(realParent, filepath.Base(path))
+		}
+	}
+	if realPath != "" {
+		if realPath = normalizePath(realPath); realPath != forms[0] {
+			forms = append(forms, realPath)
+		}
+	}
+	return forms
+}
+
+// normalizePath 规范化路径，macOS 和 Windows 的文件系统默认不区分大小写
+func normalizePath(path string) string {
+	path = filepath.Clean(path)
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// isFilesystemRoot 判断路径是否为文件系统根目录（包括 Windows 的盘符根目录和共享根目录）
+func isFilesystemRoot(path string) bool {
+	if filepath.Dir(path) == path {
+		return true
+	}
+	volume := filepath.VolumeName(path)
+	return volume != "" && strings.TrimRight(path[len(volume):], `\/`) == ""
+}
@@ -0,0 +1,108 @@
+package cleaner
+
+import (
+	"errors"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tempHome 使用临时的主目录，测试会在主目录中创建文件
+func tempHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	return home
+}
+
+func TestProtector(t *testing.T) {
+	home := tempHome(t)
+	configDir := filepath.Join(home, utils.ConfigDirName)
+
+	root := t.TempDir()
+	scanRoot := filepath.Join(root, "projects")
+	userProtected := filepath.Join(root, "important")
+	newDir(t, filepath.Join(scanRoot, "app", "node_modules"), 1)
+	newDir(t, filepath.Join(userProtected, "node_modules"), 1)
+	symlink(t, home, filepath.Join(root, "home-link"))
+	symlink(t, userProtected, filepath.Join(scanRoot, "important-link"))
+
+	p := NewProtector([]string{scanRoot}, []string{userProtected})
+
+	sep := string(filepath.Separator)
+	tests := []struct {
+		name    string
+		path    string
+		allowed bool
+	}{
+		{"扫描路径中的目录", filepath.Join(scanRoot, "app", "node_modules"), true},
+		{"已不存在的目录", filepath.Join(scanRoot, "gone", "node_modules"), true},
+		{"空路径", "", false},
+		{"相对路径", filepath.Join("projects", "app"), false},
+		{"根目录", filepath.VolumeName(root) + sep, false},
+		{"根目录加 ..", filepath.VolumeName(root) + sep + ".." + sep, false},
+		{"根目录重复分隔符", filepath.VolumeName(root) + sep + sep, false},
+		{"主目录", home, false},
+		{"主目录末尾分隔符", home + sep, false},
+		{"主目录末尾 .", home + sep + ".", false},
+		{"通过 .. 回到主目录", filepath.Join(home, "a") + sep + "..", false},
+		{"主目录的上级目录", filepath.Dir(home), false},
+		{"指向主目录的符号链接", filepath.Join(root, "home-link"), false},
+		{"配置目录", configDir, false},
+		{"配置目录中的内容", filepath.Join(configDir, "quarantine", "x", "data"), false},
+		{"扫描根目录", scanRoot, false},
+		{"扫描根目录的上级目录", root, false},
+		{"用户定义的受保护路径", userProtected, false},
+		{"用户定义的受保护路径中的内容", filepath.Join(userProtected, "node_modules"), false},
+		{"通过符号链接进入受保护路径", filepath.Join(scanRoot, "important-link", "node_modules"), false},
+		{"版本控制目录中的内容", filepath.Join(scanRoot, "app", ".git", "node_modules"), false},
+		{"系统目录中的内容", filepath.Join(scanRoot, "Library", "Caches", "target"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Check(tt.path)
+			if tt.allowed && err != nil {
+				t.Errorf("Check(%q) error = %v, want allowed", tt.path, err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrProtectedPath) {
+				t.Errorf("Check(%q) error = %v, want ErrProtectedPath", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestCleanNeverRemovesProtectedPaths(t *testing.T) {
+	home := tempHome(t)
+	marker := filepath.Join(home, "keep")
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	symlink(t, home, filepath.Join(root, "home-link"))
+
+	// 即使没有设置校验器，受保护路径也不会被删除
+	c := New(PermanentRemover{}, nil, nil)
+	events := collectProgress(c)
+	result, err := c.Clean([]models.ScanItem{
+		{Path: home, Selected: true},
+		{Path: filepath.Join(home, "sub") + string(filepath.Separator) + "..", Selected: true},
+		{Path: filepath.Join(root, "home-link"), Selected: true},
+	})
+	c.Close()
+	<-events
+	if err != nil {
+		t.Fatalf("Clean() error: %v", err)
+	}
+
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("home directory content was removed: %v", err)
+	}
+	if result.RejectedCount != len(result.Items) {
+		t.Errorf("result = %+v, want all items rejected", result)
+	}
+}
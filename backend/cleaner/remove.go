@@ -0,0 +1,58 @@
+package cleaner
+
+import (
+	"errors"
+	"fast-clean-x/backend/utils"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// removeAll 递归删除路径，按 sizeMode 返回实际删除的大小
+// 与 os.RemoveAll 一样遇到错误时继续删除其余内容并返回第一个错误，
+// 但会逐个文件统计已删除的大小，中途失败时也能得到准确的释放空间
+func removeAll(path string, sizeMode string) (int64, error) {
+	var counter utils.UsageCounter
+	err := removeTree(path, &counter)
+	return counter.Usage.Size(sizeMode), err
+}
+
+// removeTree 递归删除路径，将成功删除的文件和目录累计到 counter
+func removeTree(path string, counter *utils.UsageCounter) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		counter.Add(info)
+		return nil
+	}
+
+	var firstErr error
+
+	entries, err := os.ReadDir(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		firstErr = err
+	}
+	for _, entry := range entries {
+		if err := removeTree(filepath.Join(path, entry.Name()), counter); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		if firstErr == nil {
+			firstErr = err
+		}
+		return firstErr
+	}
+	counter.Add(info)
+	return firstErr
+}
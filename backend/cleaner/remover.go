@@ -0,0 +1,72 @@
+package cleaner
+
+import (
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/quarantine"
+	"fast-clean-x/backend/utils"
+	"fmt"
+)
+
+// Remover 删除策略，决定扫描项被清理时如何处理
+type Remover interface {
+	// Remove 删除扫描项，返回实际从原位置移除的大小
+	// 出错时返回值为出错前已经移除的大小
+	Remove(item models.ScanItem) (int64, error)
+}
+
+// Keeper 由把扫描项移到别处保留的删除策略实现，如回收站和隔离区
+// 它们移除的大小记为移走（MovedSize）而不是释放，清空回收站或隔离区后才释放空间
+type Keeper interface {
+	KeepsRemoved() bool
+}
+
+// PermanentRemover 永久删除，无法恢复
+type PermanentRemover struct {
+	SizeMode string // 大小统计方式，见 models.SizeMode* 常量
+}
+
+// Remove 永久删除扫描项，逐个文件统计释放的大小
+func (r PermanentRemover) Remove(item models.ScanItem) (int64, error) {
+	return removeAll(item.Path, r.SizeMode)
+}
+
+// TrashRemover 移到系统回收站，可以从回收站恢复
+type TrashRemover struct {
+	SizeMode string // 大小统计方式，见 models.SizeMode* 常量
+}
+
+// Remove 将扫描项移到系统回收站
+// 移动是原子操作，先统计当前大小，移动失败时视为没有移除任何内容
+func (r TrashRemover) Remove(item models.ScanItem) (int64, error) {
+	usage, _ := utils.MeasureDir(item.Path)
+	if err := moveToTrash(item.Path); err != nil {
+		return 0, err
+	}
+	return usage.Size(r.SizeMode), nil
+}
+
+// KeepsRemoved 移到回收站的内容仍然占用空间，实现 Keeper
+func (r TrashRemover) KeepsRemoved() bool {
+	return true
+}
+
+// NewRemover 根据删除方式创建删除策略，空字符串表示使用永久删除
+// sizeMode 为统计移除大小的方式，见 models.SizeMode* 常量
+func NewRemover(mode string, sizeMode string) (Remover, error) {
+	switch mode {
+	case "", models.DeleteModePermanent:
+		return PermanentRemover{SizeMode: sizeMode}, nil
+	case models.DeleteModeTrash:
+		return TrashRemover{SizeMode: sizeMode}, nil
+	case models.DeleteModeQuarantine:
+		// 隔离区本身实现了 Remover，移入时记录原始路径、规则和大小
+		store, err := quarantine.Open()
+		if err != nil {
+			return nil, err
+		}
+		store.SetSizeMode(sizeMode)
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown delete mode: %s", mode)
+	}
+}
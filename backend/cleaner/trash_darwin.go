@@ -0,0 +1,40 @@
+package cleaner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// moveToTrash 将路径移到 ~/.Trash
+// 与访达不同，这里不记录原始位置，恢复时需要手动拖回原目录
+func moveToTrash(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	trashDir := filepath.Join(home, ".Trash")
+
+	base := filepath.Base(absPath)
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = base + " " + strconv.Itoa(i)
+		}
+
+		target := filepath.Join(trashDir, name)
+		if _, err := os.Lstat(target); err == nil {
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return os.Rename(absPath, target)
+	}
+}
@@ -0,0 +1,186 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// 按照 freedesktop.org Trash 规范实现回收站：
+// https://specifications.freedesktop.org/trash-spec/trashspec-latest.html
+//
+// 与主目录在同一文件系统上的文件移到 $XDG_DATA_HOME/Trash，
+// 其他挂载点上的文件移到该挂载点的 $topdir/.Trash/$uid 或 $topdir/.Trash-$uid，
+// 避免跨文件系统复制整个目录树。
+
+// moveToTrash 将路径移到回收站
+func moveToTrash(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return err
+	}
+	dev := deviceOf(info)
+
+	trashDir, topDir, err := findTrashDir(absPath, dev)
+	if err != nil {
+		return err
+	}
+
+	// 主目录回收站记录绝对路径，挂载点回收站记录相对于挂载点的路径
+	infoPath := absPath
+	if topDir != "" {
+		if rel, err := filepath.Rel(topDir, absPath); err == nil {
+			infoPath = rel
+		}
+	}
+
+	return trashInto(trashDir, absPath, infoPath, time.Now())
+}
+
+// findTrashDir 查找与路径位于同一文件系统的回收站目录
+// 返回回收站目录和挂载点目录（使用主目录回收站时挂载点为空）
+func findTrashDir(path string, dev uint64) (string, string, error) {
+	homeTrash, err := homeTrashDir()
+	if err == nil {
+		if err := os.MkdirAll(homeTrash, 0700); err == nil {
+			if info, err := os.Stat(homeTrash); err == nil && deviceOf(info) == dev {
+				return homeTrash, "", nil
+			}
+		}
+	}
+
+	topDir := mountPoint(path, dev)
+	uid := strconv.Itoa(os.Getuid())
+
+	// 优先使用管理员创建的 $topdir/.Trash/$uid（必须是设置了粘滞位的真实目录）
+	adminTrash := filepath.Join(topDir, ".Trash")
+	if info, err := os.Lstat(adminTrash); err == nil &&
+		info.IsDir() && info.Mode()&os.ModeSymlink == 0 && info.Mode()&os.ModeSticky != 0 {
+		userTrash := filepath.Join(adminTrash, uid)
+		if err := os.MkdirAll(userTrash, 0700); err == nil {
+			return userTrash, topDir, nil
+		}
+	}
+
+	// 其次使用 $topdir/.Trash-$uid
+	userTrash := filepath.Join(topDir, ".Trash-"+uid)
+	if err := os.MkdirAll(userTrash, 0700); err != nil {
+		return "", "", fmt.Errorf("no usable trash directory for %s: %w", path, err)
+	}
+	if info, err := os.Lstat(userTrash); err != nil || !info.IsDir() || info.Mode()&os.ModeSymlink != 0 {
+		return "", "", fmt.Errorf("invalid trash directory: %s", userTrash)
+	}
+	return userTrash, topDir, nil
+}
+
+// homeTrashDir 返回主目录回收站 $XDG_DATA_HOME/Trash
+func homeTrashDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// mountPoint 向上查找路径所在文件系统的挂载点
+func mountPoint(path string, dev uint64) string {
+	current := path
+	for {
+		parent := filepath.Dir(current)
+		if parent == current {
+			return current
+		}
+		info, err := os.Lstat(parent)
+		if err != nil || deviceOf(info) != dev {
+			return current
+		}
+		current = parent
+	}
+}
+
+// trashInto 将路径移到指定的回收站目录，并写入 .trashinfo 文件
+func trashInto(trashDir, path, infoPath string, deletedAt time.Time) error {
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		escapeTrashPath(infoPath), deletedAt.Format("2006-01-02T15:04:05"))
+
+	base := filepath.Base(path)
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = base + "_" + strconv.Itoa(i)
+		}
+
+		// 以独占方式创建 info 文件来占用名称，避免与其他程序冲突
+		infoFile := filepath.Join(infoDir, name+".trashinfo")
+		f, err := os.OpenFile(infoFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = f.WriteString(content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(infoFile)
+			return err
+		}
+
+		target := filepath.Join(filesDir, name)
+		if _, err := os.Lstat(target); err == nil {
+			// files 中存在没有 info 的残留文件，换一个名称
+			os.Remove(infoFile)
+			continue
+		}
+
+		if err := os.Rename(path, target); err != nil {
+			os.Remove(infoFile)
+			return err
+		}
+		return nil
+	}
+}
+
+// escapeTrashPath 按规范对路径进行 URL 编码
+func escapeTrashPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// deviceOf 返回文件所在设备号
+func deviceOf(info os.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev)
+	}
+	return 0
+}
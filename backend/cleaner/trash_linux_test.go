@@ -0,0 +1,68 @@
+package cleaner
+
+import (
+	"fast-clean-x/backend/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveToTrash(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+
+	// 两个同名目录，第二个应该自动改名
+	paths := []string{
+		filepath.Join(root, "a b", "node_modules"),
+		filepath.Join(root, "c", "node_modules"),
+	}
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Join(path, "pkg"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (TrashRemover{}).Remove(models.ScanItem{Path: path}); err != nil {
+			t.Fatalf("Remove(%s) error: %v", path, err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s still exists after moving to trash", path)
+		}
+	}
+
+	trashDir := filepath.Join(root, "data", "Trash")
+	for i, name := range []string{"node_modules", "node_modules_2"} {
+		if _, err := os.Stat(filepath.Join(trashDir, "files", name, "pkg")); err != nil {
+			t.Errorf("trashed directory %s not found: %v", name, err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(trashDir, "info", name+".trashinfo"))
+		if err != nil {
+			t.Fatalf("trashinfo for %s not found: %v", name, err)
+		}
+		content := string(data)
+		if !strings.HasPrefix(content, "[Trash Info]\n") {
+			t.Errorf("trashinfo missing header: %q", content)
+		}
+		wantPath := "Path=" + escapeTrashPath(paths[i]) + "\n"
+		if !strings.Contains(content, wantPath) {
+			t.Errorf("trashinfo = %q, want line %q", content, wantPath)
+		}
+		if !strings.Contains(content, "\nDeletionDate=") {
+			t.Errorf("trashinfo missing DeletionDate: %q", content)
+		}
+	}
+}
+
+func TestEscapeTrashPath(t *testing.T) {
+	tests := map[string]string{
+		"/home/user/a b/node_modules": "/home/user/a%20b/node_modules",
+		"project/100%/target":         "project/100%25/target",
+		"/tmp/项目":                     "/tmp/%E9%A1%B9%E7%9B%AE",
+	}
+
+	for input, expected := range tests {
+		if result := escapeTrashPath(input); result != expected {
+			t.Errorf("escapeTrashPath(%q) = %q, want %q", input, result, expected)
+		}
+	}
+}
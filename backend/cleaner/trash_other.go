@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// moveToTrash 当前平台不支持移到回收站
+func moveToTrash(path string) error {
+	return fmt.Errorf("move to trash is not supported on %s: %w", runtime.GOOS, errors.ErrUnsupported)
+}
@@ -0,0 +1,115 @@
+package cleaner
+
+import (
+	"errors"
+	"fast-clean-x/backend/matcher"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Validator 删除前重新校验扫描项
+// 扫描和清理之间文件系统可能已经变化，前端传入的扫描项也不能完全信任
+type Validator struct {
+	roots []scanRoot
+	rules map[string]*matcher.Rule
+}
+
+// scanRoot 扫描根目录，同时保存解析符号链接后的真实路径
+type scanRoot struct {
+	path     string
+	realPath string
+}
+
+// NewValidator 创建校验器，scanPaths 为允许清理的扫描根目录，rules 为扫描规则
+// 禁用的规则被忽略，由它们匹配的扫描项会被拒绝
+func NewValidator(scanPaths []string, rules []models.ScanRule) *Validator {
+	v := &Validator{
+		roots: make([]scanRoot, 0, len(scanPaths)),
+		rules: make(map[string]*matcher.Rule, len(rules)),
+	}
+
+	for _, path := range scanPaths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		realPath, err := filepath.EvalSymlinks(absPath)
+		if err != nil {
+			realPath = absPath
+		}
+		v.roots = append(v.roots, scanRoot{path: absPath, realPath: realPath})
+	}
+
+	for _, rule := range rules {
+		if rule.Enabled {
+			v.rules[rule.Name] = matcher.CompileRule(rule)
+		}
+	}
+	return v
+}
+
+// Validate 校验扫描项是否仍然可以删除，返回拒绝的原因
+func (v *Validator) Validate(item models.ScanItem) error {
+	if !filepath.IsAbs(item.Path) {
+		return fmt.Errorf("path is not absolute: %s", item.Path)
+	}
+	path := filepath.Clean(item.Path)
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return errors.New("path is a symlink")
+	}
+	if !info.IsDir() {
+		return errors.New("path is not a directory")
+	}
+
+	if err := v.checkRoot(path); err != nil {
+		return err
+	}
+	return v.checkRule(path, item.Type)
+}
+
+// checkRoot 检查路径是否位于某个扫描根目录之下（不能是根目录本身）
+// 同时检查解析符号链接后的路径，防止上级目录被替换为指向其他位置的链接
+func (v *Validator) checkRoot(path string) error {
+	realParent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	realPath := filepath.Join(realParent, filepath.Base(path))
+
+	for _, root := range v.roots {
+		if utils.IsUnder(path, root.path) && utils.IsUnder(realPath, root.realPath) {
+			return nil
+		}
+	}
+	return errors.New("path is not under any configured scan path")
+}
+
+// checkRule 检查目录是否仍然匹配规则的目标目录模式、项目标识是否还在，以及是否通过必需的内容校验
+func (v *Validator) checkRule(path string, ruleName string) error {
+	rule, ok := v.rules[ruleName]
+	if !ok {
+		return fmt.Errorf("unknown scan rule: %q", ruleName)
+	}
+
+	pattern, ok := rule.MatchTarget(path)
+	if !ok {
+		return fmt.Errorf("directory %q does not match rule %s", filepath.Base(path), rule.Name)
+	}
+
+	if _, ok := rule.MatchMarkers(path, pattern); !ok {
+		return fmt.Errorf("project marker for rule %s not found", rule.Name)
+	}
+
+	if rule.Verify(path, pattern) == matcher.VerifyRejected {
+		return fmt.Errorf("directory %q does not pass the required content check of rule %s", filepath.Base(path), rule.Name)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package cleaner
+
+import (
+	"fast-clean-x/backend/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	root := t.TempDir()
+	scanRoot := filepath.Join(root, "projects")
+	outside := filepath.Join(root, "outside")
+
+	// 正常的 Maven 项目
+	newDir(t, filepath.Join(scanRoot, "app", "target"), 1)
+	writeFile(t, filepath.Join(scanRoot, "app", "pom.xml"))
+	// 项目标识已被删除
+	newDir(t, filepath.Join(scanRoot, "gone", "target"), 1)
+	// 目录名不匹配规则
+	newDir(t, filepath.Join(scanRoot, "app", "src"), 1)
+	// 扫描路径之外的项目
+	newDir(t, filepath.Join(outside, "app", "target"), 1)
+	writeFile(t, filepath.Join(outside, "app", "pom.xml"))
+	// 扫描后目标目录被替换为符号链接
+	writeFile(t, filepath.Join(scanRoot, "linked", "pom.xml"))
+	symlink(t, filepath.Join(outside, "app", "target"), filepath.Join(scanRoot, "linked", "target"))
+	// 扫描后上级目录被替换为指向扫描路径之外的符号链接
+	symlink(t, filepath.Join(outside, "app"), filepath.Join(scanRoot, "moved"))
+	// 多段路径模式
+	newDir(t, filepath.Join(scanRoot, "dotnet", "bin", "Debug"), 1)
+	newDir(t, filepath.Join(scanRoot, "dotnet", "obj", "Debug"), 1)
+	// CACHEDIR.TAG 检测器
+	newDir(t, filepath.Join(scanRoot, "tagged"), 1)
+	tag := []byte("Signature: 8a477f597d28d172789f06886806bc55\n")
+	if err := os.WriteFile(filepath.Join(scanRoot, "tagged", "CACHEDIR.TAG"), tag, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 必需的内容校验
+	newDir(t, filepath.Join(scanRoot, "strict", "out"), 1)
+	newDir(t, filepath.Join(scanRoot, "built", "out"), 1)
+	writeFile(t, filepath.Join(scanRoot, "built", "out", "BUILD_MARKER"))
+
+	v := NewValidator([]string{scanRoot}, []models.ScanRule{{
+		Name:           "Maven",
+		TargetDirs:     []string{"target"},
+		ProjectMarkers: []string{"pom.xml"},
+		RequireMarkers: true,
+		Enabled:        true,
+	}, {
+		Name:       ".NET",
+		TargetDirs: []string{"bin/Debug"},
+		Enabled:    true,
+	}, {
+		Name:     "CACHEDIR.TAG",
+		Detector: models.DetectorCacheDirTag,
+		Enabled:  true,
+	}, {
+		Name:       "Strict",
+		TargetDirs: []string{"out"},
+		Verifiers:  []models.TargetVerifier{{Target: "out", Contains: []string{"BUILD_MARKER"}, Required: true}},
+		Enabled:    true,
+	}, {
+		Name:       "Disabled",
+		TargetDirs: []string{"src"},
+	}})
+
+	tests := []struct {
+		name  string
+		path  string
+		rule  string
+		valid bool
+	}{
+		{"有效的项目", filepath.Join(scanRoot, "app", "target"), "Maven", true},
+		{"相对路径", filepath.Join("projects", "app", "target"), "Maven", false},
+		{"扫描路径之外", filepath.Join(outside, "app", "target"), "Maven", false},
+		{"扫描路径本身", scanRoot, "Maven", false},
+		{"路径中包含 ..", filepath.Join(scanRoot, "..", "outside", "app", "target"), "Maven", false},
+		{"目录名不匹配", filepath.Join(scanRoot, "app", "src"), "Maven", false},
+		{"未知规则", filepath.Join(scanRoot, "app", "target"), "Unknown", false},
+		{"项目标识消失", filepath.Join(scanRoot, "gone", "target"), "Maven", false},
+		{"目标变成符号链接", filepath.Join(scanRoot, "linked", "target"), "Maven", false},
+		{"上级目录是符号链接", filepath.Join(scanRoot, "moved", "target"), "Maven", false},
+		{"匹配多段路径模式", filepath.Join(scanRoot, "dotnet", "bin", "Debug"), ".NET", true},
+		{"不匹配多段路径模式", filepath.Join(scanRoot, "dotnet", "obj", "Debug"), ".NET", false},
+		{"带有 CACHEDIR.TAG", filepath.Join(scanRoot, "tagged"), "CACHEDIR.TAG", true},
+		{"CACHEDIR.TAG 不存在", filepath.Join(scanRoot, "dotnet", "obj"), "CACHEDIR.TAG", false},
+		{"通过必需的内容校验", filepath.Join(scanRoot, "built", "out"), "Strict", true},
+		{"未通过必需的内容校验", filepath.Join(scanRoot, "strict", "out"), "Strict", false},
+		{"规则已禁用", filepath.Join(scanRoot, "app", "src"), "Disabled", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(models.ScanItem{Path: tt.path, Type: tt.rule})
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate(%s) error = %v, want valid = %v", tt.path, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestCleanRejectsInvalidItems(t *testing.T) {
+	root := t.TempDir()
+	valid := filepath.Join(root, "a", "node_modules")
+	invalid := filepath.Join(root, "b", "src")
+	newDir(t, valid, 10)
+	newDir(t, invalid, 10)
+
+	c := New(PermanentRemover{}, nil, nil)
+	c.SetValidator(NewValidator([]string{root}, []models.ScanRule{{Name: "Node.js", TargetDirs: []string{"node_modules"}, Enabled: true}}))
+	events := collectProgress(c)
+	result, err := c.Clean([]models.ScanItem{
+		{Path: valid, Type: "Node.js", Size: 10, Selected: true},
+		{Path: invalid, Type: "Node.js", Size: 10, Selected: true},
+	})
+	c.Close()
+	<-events
+	if err != nil {
+		t.Fatalf("Clean() error: %v", err)
+	}
+
+	if _, err := os.Stat(invalid); err != nil {
+		t.Errorf("rejected item was removed: %v", err)
+	}
+	if result.CleanedCount != 1 || result.RejectedCount != 1 || result.Items[1].Status != models.CleanStatusRejected {
+		t.Errorf("result = %+v, want 1 cleaned and 1 rejected", result)
+	}
+	if progress := c.Progress(); len(progress.Rejections) != 1 || progress.Rejections[0].Path != invalid {
+		t.Errorf("Progress().Rejections = %+v, want %s", progress.Rejections, invalid)
+	}
+}
+
+// writeFile 创建空文件及其上级目录
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// symlink 创建符号链接及其上级目录
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlink not supported: %v", err)
+	}
+}
@@ -0,0 +1,408 @@
+package cli
+
+import (
+	"bufio"
+	"fast-clean-x/backend/cleaner"
+	"fast-clean-x/backend/config"
+	"fast-clean-x/backend/history"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/scanner"
+	"fast-clean-x/backend/utils"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// 退出码
+const (
+	ExitOK    = 0 // 成功
+	ExitError = 1 // 执行出错
+	ExitUsage = 2 // 参数错误
+)
+
+// CLI 命令行入口，与桌面应用共享扫描器、清理器和配置
+type CLI struct {
+	stdin         io.Reader
+	stdout        io.Writer
+	stderr        io.Writer
+	configManager *config.Manager
+}
+
+// New 创建命令行入口
+func New(stdin io.Reader, stdout, stderr io.Writer) *CLI {
+	return &CLI{
+		stdin:         stdin,
+		stdout:        stdout,
+		stderr:        stderr,
+		configManager: config.GetManager(),
+	}
+}
+
+// stringList 可重复的字符串参数，如 --path a --path b
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Run 解析参数并执行子命令，返回进程退出码
+func (c *CLI) Run(args []string) int {
+	if len(args) == 0 {
+		c.usage()
+		return ExitUsage
+	}
+
+	// 提示加载配置时的变化，如与内置规则重名而被重命名的自定义规则
+	for _, notice := range c.configManager.Notices() {
+		fmt.Fprintf(c.stderr, "提示: %s\n", notice)
+	}
+
+	var err error
+	switch args[0] {
+	case "scan":
+		err = c.runScan(args[1:])
+	case "clean":
+		err = c.runClean(args[1:])
+	case "rules":
+		err = c.runRules(args[1:])
+	case "explain":
+		err = c.runExplain(args[1:])
+	case "caches":
+		err = c.runCaches(args[1:])
+	case "config":
+		err = c.runConfig(args[1:])
+	case "quarantine":
+		err = c.runQuarantine(args[1:])
+	case "history":
+		err = c.runHistory(args[1:])
+	case "help", "-h", "--help":
+		c.usage()
+		return ExitOK
+	default:
+		fmt.Fprintf(c.stderr, "未知命令: %s\n\n", args[0])
+		c.usage()
+		return ExitUsage
+	}
+
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	if err != nil {
+		if _, ok := err.(usageError); ok {
+			fmt.Fprintf(c.stderr, "参数错误: %v\n", err)
+			return ExitUsage
+		}
+		fmt.Fprintf(c.stderr, "错误: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// usageError 参数错误
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// usage 打印帮助信息
+func (c *CLI) usage() {
+	fmt.Fprint(c.stderr, `用法: fast-clean-x <命令> [参数]
+
+命令:
+  scan        扫描配置的路径（或 --path 指定的路径），列出可清理的目录
+  clean       扫描并清理可清理的目录
+  rules       查看或启用/禁用扫描规则
+  explain     说明目录为什么会或不会被扫描为可清理项
+  caches      查看或清理 Maven、Gradle、npm 等工具在项目之外的全局缓存
+  config      查看或修改配置（~/.fast-clean-x/config.json）
+  quarantine  查看、恢复或永久删除隔离区中的目录
+  history     查看或删除扫描和清理历史
+
+使用 "fast-clean-x <命令> -h" 查看命令的详细参数
+`)
+}
+
+// newFlagSet 创建子命令的参数解析器
+func (c *CLI) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// scanOptions 扫描相关的公共参数
+type scanOptions struct {
+	paths stringList
+	rules stringList
+	quiet bool
+	full  bool
+}
+
+// bind 注册扫描相关参数
+func (o *scanOptions) bind(fs *flag.FlagSet) {
+	fs.Var(&o.paths, "path", "扫描路径，可重复指定（默认使用配置中的扫描路径）")
+	fs.Var(&o.rules, "rule", "只使用指定的规则，可重复指定（默认使用配置中启用的规则）")
+	fs.BoolVar(&o.quiet, "quiet", false, "不输出扫描进度")
+	fs.BoolVar(&o.full, "full", false, "忽略增量扫描缓存，完整重新扫描")
+}
+
+// scanPaths 返回本次扫描的路径，未指定时使用配置中的扫描路径
+// 命令行指定的路径转换为绝对路径，删除前校验只接受绝对路径
+func (o *scanOptions) scanPaths(cfg *models.Config) []string {
+	if len(o.paths) == 0 {
+		return cfg.ScanPaths
+	}
+	paths := make([]string, 0, len(o.paths))
+	for _, path := range o.paths {
+		if absPath, err := filepath.Abs(path); err == nil {
+			path = absPath
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// scan 按配置执行一次扫描，进度输出到 stderr
+func (c *CLI) scan(opts *scanOptions) (*models.ScanResult, error) {
+	cfg := c.configManager.GetConfig()
+
+	paths := opts.scanPaths(cfg)
+	if len(paths) == 0 {
+		return nil, usageError("no scan paths configured, use --path or `fast-clean-x config add-path`")
+	}
+
+	rules, err := c.selectRules(opts.rules)
+	if err != nil {
+		return nil, err
+	}
+
+	s := scanner.New(rules, cfg.IgnorePatterns, cfg.GlobalPathExcludes)
+	s.SetSizeMode(cfg.SizeMode)
+
+	cache, cacheErr := scanner.OpenCache()
+	if cacheErr == nil {
+		if opts.full {
+			cache.Reset()
+		}
+		s.SetCache(cache)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for progress := range s.GetProgressChan() {
+			if !opts.quiet && progress.IsScanning {
+				c.printStatus("扫描中 [%3d%%] 已发现 %d 项 %s: %s", progress.Progress,
+					progress.MatchedCount, utils.FormatSize(progress.TotalSize), progress.CurrentPath)
+			}
+		}
+	}()
+
+	stop := c.onInterrupt(s.Cancel)
+	startTime := time.Now()
+	result, err := s.Scan(paths)
+	stop()
+
+	s.Close()
+	<-done
+	if !opts.quiet {
+		c.clearStatus()
+	}
+
+	// 只有完整扫描了所有路径才更新缓存
+	if cacheErr == nil && result != nil && result.Complete {
+		_ = cache.Save()
+	}
+
+	_ = c.configManager.SetLastScanTime(startTime)
+	_ = history.Save(history.NewScanRecord(paths, result, startTime, err))
+
+	return result, err
+}
+
+// selectRules 根据名称选择规则，未指定时返回配置中启用的规则
+func (c *CLI) selectRules(names []string) ([]models.ScanRule, error) {
+	if len(names) == 0 {
+		return c.configManager.GetEnabledRules(), nil
+	}
+
+	all := c.configManager.GetConfig().ScanRules
+	rules := make([]models.ScanRule, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, rule := range all {
+			if strings.EqualFold(rule.Name, name) {
+				rule.Enabled = true
+				rules = append(rules, rule)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, usageError(fmt.Sprintf("unknown rule: %s", name))
+		}
+	}
+	return rules, nil
+}
+
+// newCleaner 创建清理器，只允许清理本次扫描路径下仍然匹配本次扫描所用规则的项目
+// 本次和配置中的扫描路径本身，以及用户定义的受保护路径永远不会被清理
+func (c *CLI) newCleaner(remover cleaner.Remover, opts *scanOptions, concurrency int) (*cleaner.Cleaner, error) {
+	cfg := c.configManager.GetConfig()
+	rules, err := c.selectRules(opts.rules)
+	if err != nil {
+		return nil, err
+	}
+	paths := opts.scanPaths(cfg)
+	scanPaths := append(append([]string{}, paths...), cfg.ScanPaths...)
+	cl := cleaner.New(remover, scanPaths, cfg.ProtectedPaths)
+	cl.SetSizeMode(cfg.SizeMode)
+	cl.SetValidator(cleaner.NewValidator(paths, rules))
+	cl.SetConcurrency(c.concurrency(concurrency))
+	return cl, nil
+}
+
+// clean 清理选中的项目，进度输出到 stderr
+func (c *CLI) clean(items []models.ScanItem, opts *scanOptions, mode string, concurrency int) (*models.CleanResult, error) {
+	if mode == "" {
+		mode = c.configManager.GetConfig().DeleteMode
+	}
+	if mode == "" {
+		mode = models.DeleteModePermanent
+	}
+	sizeMode := c.configManager.GetConfig().SizeMode
+	remover, err := cleaner.NewRemover(mode, sizeMode)
+	if err != nil {
+		return nil, usageError(err.Error())
+	}
+
+	cl, err := c.newCleaner(remover, opts, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *models.CleanResult
+	startTime := time.Now()
+	err = c.runCleaner(cl, opts.quiet, func() error {
+		var err error
+		result, err = cl.Clean(items)
+		return err
+	})
+
+	_ = history.Save(history.NewCleanRecord(result, mode, startTime, err))
+
+	return result, err
+}
+
+// concurrency 返回清理并发数，未指定时使用配置
+func (c *CLI) concurrency(n int) int {
+	if n > 0 {
+		return n
+	}
+	return c.configManager.GetConfig().CleanConcurrency
+}
+
+// plan 模拟清理选中的项目，返回清理计划
+func (c *CLI) plan(items []models.ScanItem, opts *scanOptions, concurrency int) (*models.CleanPlan, error) {
+	cl, err := c.newCleaner(nil, opts, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan *models.CleanPlan
+	err = c.runCleaner(cl, opts.quiet, func() error {
+		var err error
+		plan, err = cl.DryRun(items)
+		return err
+	})
+	return plan, err
+}
+
+// runCleaner 执行清理操作，期间将进度输出到 stderr 并响应 Ctrl+C
+func (c *CLI) runCleaner(cl *cleaner.Cleaner, quiet bool, run func() error) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for progress := range cl.GetProgressChan() {
+			if !quiet && progress.IsCleaning {
+				c.printStatus("清理中 [%3d%%]: %s", progress.Progress, progress.CurrentPath)
+			}
+		}
+	}()
+
+	stop := c.onInterrupt(cl.Cancel)
+	err := run()
+	stop()
+
+	cl.Close()
+	<-done
+	if !quiet {
+		c.clearStatus()
+	}
+	return err
+}
+
+// onInterrupt 收到 Ctrl+C 时执行取消函数，返回的函数用于停止监听
+func (c *CLI) onInterrupt(cancel func()) func() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigChan:
+			fmt.Fprintln(c.stderr, "\n正在取消...")
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigChan)
+		close(done)
+	}
+}
+
+// printStatus 在 stderr 的同一行刷新状态
+func (c *CLI) printStatus(format string, args ...interface{}) {
+	line := []rune(fmt.Sprintf(format, args...))
+	if len(line) > 100 {
+		line = append(append(line[:47:47], []rune("...")...), line[len(line)-50:]...)
+	}
+	fmt.Fprintf(c.stderr, "\r\033[K%s", string(line))
+}
+
+// clearStatus 清除状态行
+func (c *CLI) clearStatus() {
+	fmt.Fprint(c.stderr, "\r\033[K")
+}
+
+// confirm 向用户确认操作
+func (c *CLI) confirm(prompt string) bool {
+	fmt.Fprintf(c.stderr, "%s [y/N]: ", prompt)
+	answer, _ := bufio.NewReader(c.stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// printItems 以表格形式输出扫描项，未选中（内容校验未通过）的项目加以标注
+func (c *CLI) printItems(items []models.ScanItem) {
+	for _, item := range items {
+		note := ""
+		if !item.Selected {
+			note = "  （内容校验未通过，默认不清理）"
+		}
+		fmt.Fprintf(c.stdout, "%-10s  %10s  %s%s\n", item.Type, utils.FormatSize(item.Size), item.Path, note)
+	}
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// 使用临时的主目录，避免读写真实配置
+	home, err := os.MkdirTemp("", "fast-clean-x-cli")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	os.Setenv("USERPROFILE", home)
+
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+// writeFile 创建文件及其父目录
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := New(strings.NewReader(stdin), &stdout, &stderr).Run(args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestScanAndClean(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "web", "package.json"))
+	writeFile(t, filepath.Join(root, "web", "node_modules", "lib", "index.js"))
+	writeFile(t, filepath.Join(root, "api", "pom.xml"))
+	writeFile(t, filepath.Join(root, "api", "target", "app.jar"))
+	writeFile(t, filepath.Join(root, "api", "target", "classes", "App.class"))
+
+	code, stdout, stderr := runCLI(t, "", "scan", "--quiet", "--json", "--path", root)
+	if code != ExitOK {
+		t.Fatalf("scan exit code = %d, stderr = %s", code, stderr)
+	}
+	var result models.ScanResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+	if result.TotalCount != 2 {
+		t.Fatalf("scan found %d items, want 2", result.TotalCount)
+	}
+
+	// 未确认时不删除
+	code, _, _ = runCLI(t, "n\n", "clean", "--quiet", "--path", root)
+	if code != ExitOK {
+		t.Fatalf("clean exit code = %d", code)
+	}
+	if !utils.PathExists(filepath.Join(root, "api", "target")) {
+		t.Fatal("clean without confirmation removed files")
+	}
+
+	code, stdout, stderr = runCLI(t, "", "clean", "--quiet", "--yes", "--rule", "maven", "--path", root)
+	if code != ExitOK {
+		t.Fatalf("clean exit code = %d, stderr = %s", code, stderr)
+	}
+	if utils.PathExists(filepath.Join(root, "api", "target")) {
+		t.Fatalf("target was not cleaned: %s", stdout)
+	}
+	if !utils.PathExists(filepath.Join(root, "web", "node_modules")) {
+		t.Fatal("--rule maven should not clean node_modules")
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	tests := [][]string{
+		{},
+		{"unknown"},
+		{"rules", "enable", "NoSuchRule"},
+		{"scan", "--rule", "NoSuchRule", "--path", "."},
+		{"config", "add-path"},
+		{"rules", "add", "--name", "Bad", "--target", "../src"},
+		{"rules", "delete", "Maven"},
+	}
+
+	for _, args := range tests {
+		if code, _, _ := runCLI(t, "", args...); code != ExitUsage {
+			t.Errorf("Run(%q) = %d, want %d", args, code, ExitUsage)
+		}
+	}
+}
+
+func TestCustomRules(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app", ".buckconfig"))
+	writeFile(t, filepath.Join(root, "app", "buck-out", "v2", "gen", "app"))
+
+	code, _, stderr := runCLI(t, "", "rules", "add", "--name", "Buck", "--target", "buck-out",
+		"--marker", ".buckconfig", "--require-markers")
+	if code != ExitOK {
+		t.Fatalf("rules add exit code = %d, stderr = %s", code, stderr)
+	}
+	if code, _, _ := runCLI(t, "", "rules", "add", "--name", "buck", "--target", "buck-gen"); code != ExitUsage {
+		t.Errorf("adding a duplicate rule exit code = %d, want %d", code, ExitUsage)
+	}
+
+	code, stdout, stderr := runCLI(t, "", "scan", "--quiet", "--json", "--rule", "Buck", "--path", root)
+	if code != ExitOK {
+		t.Fatalf("scan exit code = %d, stderr = %s", code, stderr)
+	}
+	var result models.ScanResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+	if result.TotalCount != 1 || result.Items[0].Type != "Buck" {
+		t.Fatalf("scan = %+v, want the buck-out directory", result)
+	}
+
+	if code, _, stderr := runCLI(t, "", "rules", "delete", "Buck"); code != ExitOK {
+		t.Fatalf("rules delete exit code = %d, stderr = %s", code, stderr)
+	}
+	if code, _, _ := runCLI(t, "", "scan", "--quiet", "--rule", "Buck", "--path", root); code != ExitUsage {
+		t.Errorf("scan with deleted rule exit code = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "api", "pom.xml"))
+	writeFile(t, filepath.Join(root, "api", "target", "classes", "App.class"))
+
+	code, stdout, stderr := runCLI(t, "", "explain", "--path", root, filepath.Join(root, "api", "target"))
+	if code != ExitOK {
+		t.Fatalf("explain exit code = %d, stderr = %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "匹配 Maven") || !strings.Contains(stdout, "Rust") {
+		t.Errorf("explain output = %s", stdout)
+	}
+
+	if code, _, _ := runCLI(t, "", "explain"); code != ExitUsage {
+		t.Errorf("explain without path exit code = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestCaches(t *testing.T) {
+	// 只统计临时主目录中的缓存
+	for _, name := range []string{"XDG_CACHE_HOME", "XDG_DATA_HOME", "LOCALAPPDATA", "GRADLE_USER_HOME", "npm_config_cache", "NPM_CONFIG_CACHE",
+		"PIP_CACHE_DIR", "CARGO_HOME", "GOMODCACHE", "GOPATH", "YARN_CACHE_FOLDER", "npm_config_store_dir"} {
+		t.Setenv(name, "")
+	}
+	repo := filepath.Join(os.Getenv("HOME"), ".m2", "repository")
+	writeFile(t, filepath.Join(repo, "junit", "junit", "4.13", "junit-4.13.pom"))
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(os.Getenv("HOME"), ".m2")) })
+
+	code, stdout, stderr := runCLI(t, "", "caches", "--json")
+	if code != ExitOK {
+		t.Fatalf("caches exit code = %d, stderr = %s", code, stderr)
+	}
+	var caches []models.GlobalCache
+	if err := json.Unmarshal([]byte(stdout), &caches); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+	found := false
+	for _, cache := range caches {
+		if cache.ID == "maven" {
+			found = cache.Exists && cache.Path == repo
+		}
+	}
+	if !found {
+		t.Errorf("caches = %+v, want the maven repository %s", caches, repo)
+	}
+
+	// 模拟清理不需要确认，也不删除文件
+	code, stdout, stderr = runCLI(t, "", "caches", "clean", "--dry-run", "--mode", "clear", "maven")
+	if code != ExitOK || !strings.Contains(stdout, "将释放") {
+		t.Fatalf("caches clean --dry-run = %d, %s, %s", code, stdout, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "junit")); err != nil {
+		t.Errorf("dry run removed files: %v", err)
+	}
+
+	for _, args := range [][]string{{"caches", "clean"}, {"caches", "clean", "--mode", "bad", "maven"}, {"caches", "nope"}} {
+		if code, _, _ := runCLI(t, "", args...); code != ExitUsage {
+			t.Errorf("%v exit code = %d, want %d", args, code, ExitUsage)
+		}
+	}
+}
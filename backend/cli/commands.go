@@ -0,0 +1,590 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fast-clean-x/backend/globalcache"
+	"fast-clean-x/backend/history"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/quarantine"
+	"fast-clean-x/backend/scanner"
+	"fast-clean-x/backend/utils"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runScan 执行 scan 子命令
+func (c *CLI) runScan(args []string) error {
+	fs := c.newFlagSet("scan")
+	var opts scanOptions
+	opts.bind(fs)
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出扫描结果")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// 取消时仍然输出已找到的部分结果
+	result, err := c.scan(&opts)
+	if result == nil || (err != nil && !result.Cancelled) {
+		return err
+	}
+	sortItems(result.Items)
+
+	if *asJSON {
+		if jsonErr := c.writeJSON(result); jsonErr != nil {
+			return jsonErr
+		}
+		return err
+	}
+
+	c.printItems(result.Items)
+	fmt.Fprintf(c.stdout, "\n共 %d 项，可释放 %s\n", result.TotalCount, utils.FormatSize(result.TotalSize))
+	if result.Cancelled {
+		fmt.Fprintln(c.stdout, "扫描已取消，以上为部分结果")
+	}
+	return err
+}
+
+// runClean 执行 clean 子命令
+func (c *CLI) runClean(args []string) error {
+	fs := c.newFlagSet("clean")
+	var opts scanOptions
+	opts.bind(fs)
+	yes := fs.Bool("yes", false, "跳过确认直接清理")
+	dryRun := fs.Bool("dry-run", false, "只输出清理计划，不删除任何文件")
+	concurrency := fs.Int("concurrency", 0, "同时清理的目录数，默认使用配置（0 表示根据磁盘类型自动选择）")
+	mode := fs.String("mode", "", "删除方式：permanent（永久删除）、trash（移到回收站）或 quarantine（移到隔离区），默认使用配置")
+	unverified := fs.Bool("unverified", false, "同时清理内容校验未通过的目录（默认不清理）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := c.scan(&opts)
+	if err != nil {
+		return err
+	}
+	if *unverified {
+		for i := range result.Items {
+			result.Items[i].Selected = true
+		}
+	}
+	selectedCount, selectedSize := 0, int64(0)
+	for _, item := range result.Items {
+		if item.Selected {
+			selectedCount++
+			selectedSize += item.Size
+		}
+	}
+	if selectedCount == 0 {
+		fmt.Fprintln(c.stdout, "没有可清理的目录")
+		return nil
+	}
+	sortItems(result.Items)
+
+	if *dryRun {
+		plan, err := c.plan(result.Items, &opts, *concurrency)
+		if err != nil {
+			return err
+		}
+		c.printPlan(plan)
+		return nil
+	}
+
+	c.printItems(result.Items)
+	fmt.Fprintf(c.stdout, "\n将清理 %d 项，可释放 %s\n", selectedCount, utils.FormatSize(selectedSize))
+	if skipped := result.TotalCount - selectedCount; skipped > 0 {
+		fmt.Fprintf(c.stdout, "另有 %d 项内容校验未通过，不会清理（使用 --unverified 一并清理）\n", skipped)
+	}
+
+	if !*yes && !c.confirm("确认删除以上目录？") {
+		fmt.Fprintln(c.stdout, "已取消")
+		return nil
+	}
+
+	cleanResult, err := c.clean(result.Items, &opts, *mode, *concurrency)
+	if cleanResult == nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "已清理 %d 项，释放 %s", cleanResult.CleanedCount, utils.FormatSize(cleanResult.CleanedSize))
+	if cleanResult.MovedSize > 0 {
+		fmt.Fprintf(c.stdout, "，移走 %s（清空回收站或隔离区后释放）", utils.FormatSize(cleanResult.MovedSize))
+	}
+	fmt.Fprintln(c.stdout)
+	if cleanResult.CancelledCount > 0 {
+		fmt.Fprintf(c.stdout, "已取消，%d 项未处理\n", cleanResult.CancelledCount)
+	}
+	if cleanResult.RejectedCount > 0 {
+		fmt.Fprintf(c.stdout, "校验未通过，拒绝删除 %d 项:\n", cleanResult.RejectedCount)
+		for _, itemResult := range cleanResult.Items {
+			if itemResult.Status == models.CleanStatusRejected {
+				fmt.Fprintf(c.stdout, "  %s: %s\n", itemResult.Path, itemResult.Error)
+			}
+		}
+	}
+	if cleanResult.FailedCount > 0 {
+		fmt.Fprintf(c.stdout, "清理失败 %d 项:\n", cleanResult.FailedCount)
+		for _, itemResult := range cleanResult.Items {
+			if itemResult.Status != models.CleanStatusFailed {
+				continue
+			}
+			fmt.Fprintf(c.stdout, "  %s: %s\n", itemResult.Path, itemResult.Error)
+			if failure := itemResult.Failure; failure != nil {
+				if failure.RemainingFiles > 0 {
+					fmt.Fprintf(c.stdout, "    已删除 %s，剩余 %d 个文件（%s）\n", utils.FormatSize(failure.RemovedSize),
+						failure.RemainingFiles, utils.FormatSize(failure.RemainingSize))
+				}
+				fmt.Fprintf(c.stdout, "    建议：%s\n", failure.Suggestion)
+			}
+		}
+		if err == nil {
+			err = fmt.Errorf("%d items failed to clean", cleanResult.FailedCount)
+		}
+	}
+	return err
+}
+
+// printPlan 输出清理计划
+func (c *CLI) printPlan(plan *models.CleanPlan) {
+	for _, item := range plan.Items {
+		switch item.Action {
+		case models.PlanActionDelete:
+			fmt.Fprintf(c.stdout, "删除  %-10s  %10s  %s\n", item.Type, item.SizeReadable, item.Path)
+		case models.PlanActionReject:
+			fmt.Fprintf(c.stdout, "拒绝  %-10s  %10s  %s（%s）\n", item.Type, "-", item.Path, item.Reason)
+		default:
+			fmt.Fprintf(c.stdout, "跳过  %-10s  %10s  %s（%s）\n", item.Type, "-", item.Path, item.Reason)
+		}
+	}
+	fmt.Fprintf(c.stdout, "\n模拟清理：将删除 %d 项，释放 %s，跳过 %d 项，拒绝 %d 项\n",
+		plan.TotalCount, utils.FormatSize(plan.TotalSize), plan.SkippedCount, plan.RejectedCount)
+}
+
+// runRules 执行 rules 子命令
+func (c *CLI) runRules(args []string) error {
+	if len(args) == 0 || args[0] == "list" {
+		for _, rule := range c.configManager.GetConfig().ScanRules {
+			status := "禁用"
+			if rule.Enabled {
+				status = "启用"
+			}
+			if rule.Custom {
+				status += "（自定义）"
+			}
+			targets := strings.Join(rule.TargetDirs, ",")
+			if rule.Detector != "" {
+				targets = "检测器: " + rule.Detector
+			}
+			fmt.Fprintf(c.stdout, "%-10s  %s  %-40s  %s\n", rule.Name, status, targets, rule.Description)
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "add":
+		return c.runRulesAdd(args[1:])
+	case "delete":
+		if len(args) != 2 {
+			return usageError("usage: fast-clean-x rules delete <name>")
+		}
+		if err := c.configManager.DeleteScanRule(args[1]); err != nil {
+			return usageError(err.Error())
+		}
+		return nil
+	}
+
+	if len(args) != 2 || (args[0] != "enable" && args[0] != "disable") {
+		return usageError("usage: fast-clean-x rules [list | enable <name> | disable <name> | add --name <name> --target <dir> | delete <name>]")
+	}
+
+	name := c.findRuleName(args[1])
+	if name == "" {
+		return usageError(fmt.Sprintf("unknown rule: %s", args[1]))
+	}
+	return c.configManager.UpdateScanRule(name, args[0] == "enable")
+}
+
+// runRulesAdd 添加自定义规则
+func (c *CLI) runRulesAdd(args []string) error {
+	fs := c.newFlagSet("rules add")
+	var rule models.ScanRule
+	var targets, markers stringList
+	fs.StringVar(&rule.Name, "name", "", "规则名称")
+	fs.StringVar(&rule.Description, "description", "", "规则描述")
+	fs.Var(&targets, "target", "要清理的目录名或路径模式（如 cmake-build-*、bin/Debug），可重复指定")
+	fs.Var(&markers, "marker", "项目标识文件，可重复指定")
+	fs.BoolVar(&rule.RequireMarkers, "require-markers", false, "必须找到项目标识才清理")
+	fs.StringVar(&rule.MarkerScope, "marker-scope", "", "项目标识的位置：ancestor（默认）、sibling、within 或 declared")
+	fs.IntVar(&rule.MarkerDepth, "marker-depth", 0, "marker-scope 为 within 时，项目标识最多位于上方几层")
+	fs.IntVar(&rule.Priority, "priority", 50, "优先级，多个规则匹配同一目录时使用优先级高的")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rule.TargetDirs = targets
+	rule.ProjectMarkers = markers
+	rule.Enabled = true
+	if err := c.configManager.AddScanRule(rule); err != nil {
+		return usageError(err.Error())
+	}
+	return nil
+}
+
+// findRuleName 按名称（不区分大小写）查找规则，返回规则的原始名称
+func (c *CLI) findRuleName(name string) string {
+	for _, rule := range c.configManager.GetConfig().ScanRules {
+		if strings.EqualFold(rule.Name, name) {
+			return rule.Name
+		}
+	}
+	return ""
+}
+
+// runConfig 执行 config 子命令
+func (c *CLI) runConfig(args []string) error {
+	if len(args) == 0 || args[0] == "show" {
+		return c.writeJSON(c.configManager.GetConfig())
+	}
+
+	switch args[0] {
+	case "path":
+		configPath, err := utils.GetConfigPath()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, configPath)
+		return nil
+	case "add-path", "remove-path", "add-protected", "remove-protected", "add-ignore", "remove-ignore", "delete-mode", "size-mode", "quarantine-days":
+		if len(args) != 2 {
+			return usageError(fmt.Sprintf("usage: fast-clean-x config %s <value>", args[0]))
+		}
+	default:
+		return usageError("usage: fast-clean-x config [show | path | add-path <dir> | remove-path <dir> | add-protected <dir> | remove-protected <dir> | add-ignore <pattern> | remove-ignore <pattern> | delete-mode <mode> | size-mode <mode> | quarantine-days <n>]")
+	}
+
+	value := args[1]
+	switch args[0] {
+	case "add-path":
+		absPath, err := filepath.Abs(value)
+		if err != nil {
+			return err
+		}
+		if !utils.IsDirectory(absPath) {
+			return fmt.Errorf("not a directory: %s", absPath)
+		}
+		return c.configManager.AddScanPath(absPath)
+	case "remove-path":
+		return c.configManager.RemoveScanPath(value)
+	case "add-protected":
+		absPath, err := filepath.Abs(value)
+		if err != nil {
+			return err
+		}
+		return c.configManager.AddProtectedPath(absPath)
+	case "remove-protected":
+		absPath, err := filepath.Abs(value)
+		if err != nil {
+			return err
+		}
+		return c.configManager.RemoveProtectedPath(absPath)
+	case "add-ignore":
+		return c.configManager.AddIgnorePattern(value)
+	case "delete-mode":
+		if err := c.configManager.SetDeleteMode(value); err != nil {
+			return usageError(err.Error())
+		}
+		return nil
+	case "size-mode":
+		if err := c.configManager.SetSizeMode(value); err != nil {
+			return usageError(err.Error())
+		}
+		return nil
+	case "quarantine-days":
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return usageError(fmt.Sprintf("invalid number of days: %s", value))
+		}
+		if err := c.configManager.SetQuarantineDays(days); err != nil {
+			return usageError(err.Error())
+		}
+		return nil
+	default:
+		return c.configManager.RemoveIgnorePattern(value)
+	}
+}
+
+// runQuarantine 执行 quarantine 子命令
+func (c *CLI) runQuarantine(args []string) error {
+	store, err := quarantine.Open()
+	if err != nil {
+		return err
+	}
+
+	retention := time.Duration(c.configManager.GetConfig().QuarantineDays) * 24 * time.Hour
+	if _, err := store.PurgeExpired(retention); err != nil {
+		return err
+	}
+
+	if len(args) == 0 || args[0] == "list" {
+		entries, err := store.List()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			fmt.Fprintf(c.stdout, "%s  %-10s  %10s  到期 %s  %s\n",
+				entry.ID, entry.Type, utils.FormatSize(entry.Size),
+				entry.QuarantinedAt.Add(retention).Format("2006-01-02 15:04"), entry.OriginalPath)
+		}
+		return nil
+	}
+
+	switch {
+	case args[0] == "restore" && len(args) == 2:
+		return store.Restore(args[1])
+	case args[0] == "purge" && len(args) == 2:
+		return store.Purge(args[1])
+	case args[0] == "purge" && len(args) == 1:
+		return store.Purge("")
+	default:
+		return usageError("usage: fast-clean-x quarantine [list | restore <id> | purge [id]]")
+	}
+}
+
+// runCaches 执行 caches 子命令
+func (c *CLI) runCaches(args []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer c.onInterrupt(cancel)()
+
+	cfg := c.configManager.GetConfig()
+	if len(args) == 0 || args[0] == "list" || strings.HasPrefix(args[0], "-") {
+		if len(args) > 0 && args[0] == "list" {
+			args = args[1:]
+		}
+		fs := c.newFlagSet("caches")
+		asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		caches, err := globalcache.List(ctx, cfg.SizeMode)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return c.writeJSON(caches)
+		}
+		for _, cache := range caches {
+			size := "不存在"
+			if cache.Exists {
+				size = cache.SizeReadable
+			}
+			prune := "不支持 prune"
+			switch {
+			case cache.PruneCommand != "":
+				prune = "prune: " + cache.PruneCommand
+			case cache.CanPrune:
+				prune = "prune: 清理长时间未使用的条目"
+			}
+			fmt.Fprintf(c.stdout, "%-7s  %10s  %s  （%s）\n", cache.ID, size, cache.Path, prune)
+		}
+		return nil
+	}
+
+	if args[0] != "clean" {
+		return usageError("usage: fast-clean-x caches [list | clean [--mode prune|clear] <id>...]")
+	}
+	fs := c.newFlagSet("caches clean")
+	mode := fs.String("mode", models.GlobalCachePrune, "清理方式：prune 只清理不再使用的条目，clear 清空整个缓存")
+	maxAge := fs.Int("max-age", globalcache.DefaultMaxAgeDays, "没有工具命令时，prune 清理多少天内没有使用的条目")
+	noTool := fs.Bool("no-tool", false, "不调用工具自带的命令，直接清理文件")
+	dryRun := fs.Bool("dry-run", false, "只计算将释放的空间，不删除")
+	yes := fs.Bool("yes", false, "不询问确认")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError("usage: fast-clean-x caches clean [--mode prune|clear] <id>...")
+	}
+	if *mode != models.GlobalCachePrune && *mode != models.GlobalCacheClear {
+		return usageError(fmt.Sprintf("invalid mode: %s", *mode))
+	}
+	if !*dryRun && !*yes && !c.confirm(fmt.Sprintf("确认以 %s 方式清理 %s？", *mode, strings.Join(fs.Args(), "、"))) {
+		fmt.Fprintln(c.stdout, "已取消")
+		return nil
+	}
+
+	opts := models.GlobalCacheCleanOptions{Mode: *mode, MaxAgeDays: *maxAge, NoTool: *noTool, DryRun: *dryRun}
+	var results []*models.GlobalCacheCleanResult
+	for _, id := range fs.Args() {
+		result, err := globalcache.Clean(ctx, id, opts, cfg.SizeMode, cfg.ProtectedPaths)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		results = append(results, result)
+		if *asJSON {
+			continue
+		}
+
+		verb := "释放"
+		if result.DryRun {
+			verb = "将释放"
+		}
+		switch result.Method {
+		case models.GlobalCacheMethodTool:
+			fmt.Fprintf(c.stdout, "%s: 执行 %s，%s %s\n", id, result.Command, verb, result.FreedReadable)
+		case models.GlobalCacheMethodEmulate:
+			fmt.Fprintf(c.stdout, "%s: 清理 %d 个 %d 天内没有使用的条目，%s %s\n", id, result.RemovedCount, *maxAge, verb, result.FreedReadable)
+		default:
+			fmt.Fprintf(c.stdout, "%s: 清空 %s，%s %s\n", id, result.Path, verb, result.FreedReadable)
+		}
+	}
+	if *asJSON {
+		return c.writeJSON(results)
+	}
+	return nil
+}
+
+// runExplain 执行 explain 子命令
+func (c *CLI) runExplain(args []string) error {
+	fs := c.newFlagSet("explain")
+	var paths stringList
+	fs.Var(&paths, "path", "扫描路径，可重复指定（默认使用配置中的扫描路径）")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("usage: fast-clean-x explain [--path <dir>] [--json] <dir>")
+	}
+
+	cfg := c.configManager.GetConfig()
+	roots := cfg.ScanPaths
+	if len(paths) > 0 {
+		roots = paths
+	}
+	s := scanner.New(cfg.ScanRules, cfg.IgnorePatterns, cfg.GlobalPathExcludes)
+	defer s.Close()
+
+	exp, err := s.Explain(fs.Arg(0), roots)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(exp)
+	}
+
+	fmt.Fprintln(c.stdout, exp.Path)
+	switch {
+	case exp.Matched:
+		fmt.Fprintf(c.stdout, "结果: 匹配 %s（置信度 %d）\n", exp.Type, exp.Confidence)
+	case exp.Skipped != "":
+		fmt.Fprintf(c.stdout, "结果: 不会扫描到，%s\n", exp.Skipped)
+	default:
+		fmt.Fprintln(c.stdout, "结果: 没有匹配的规则")
+	}
+	for _, d := range exp.Decisions {
+		mark := "✗"
+		if d.Selected {
+			mark = "✓"
+		} else if d.Matched {
+			mark = "-"
+		}
+		fmt.Fprintf(c.stdout, "  %s %-12s %s\n", mark, d.Rule, d.Reason)
+	}
+	return nil
+}
+
+// runHistory 执行 history 子命令
+func (c *CLI) runHistory(args []string) error {
+	store, err := history.Open()
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 0 && args[0] == "delete" {
+		if len(args) < 2 {
+			return usageError("usage: fast-clean-x history delete <id>...")
+		}
+		_, err := store.Delete(args[1:])
+		return err
+	}
+	if len(args) > 0 && args[0] == "clear" {
+		return store.Clear()
+	}
+	if len(args) > 0 && args[0] == "list" {
+		args = args[1:]
+	}
+
+	fs := c.newFlagSet("history")
+	kind := fs.String("kind", "", "只显示指定类型的记录：scan 或 clean")
+	since := fs.String("since", "", "只显示此日期之后的记录，格式 2006-01-02")
+	until := fs.String("until", "", "只显示此日期之前的记录，格式 2006-01-02")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter := models.HistoryFilter{Kind: *kind}
+	if *since != "" {
+		t, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+		if err != nil {
+			return usageError(fmt.Sprintf("invalid date: %s", *since))
+		}
+		filter.Since = t
+	}
+	if *until != "" {
+		t, err := time.ParseInLocation("2006-01-02", *until, time.Local)
+		if err != nil {
+			return usageError(fmt.Sprintf("invalid date: %s", *until))
+		}
+		// 包含结束日期当天
+		filter.Until = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	records, err := store.List(filter)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(records)
+	}
+
+	for _, record := range records {
+		status := ""
+		if record.Cancelled {
+			status = "（已取消）"
+		} else if record.Error != "" {
+			status = "（出错）"
+		} else if len(record.FailedItems) > 0 {
+			status = fmt.Sprintf("（失败 %d 项）", len(record.FailedItems))
+		}
+		if record.MovedSize > 0 {
+			status += fmt.Sprintf("（移走 %s）", utils.FormatSize(record.MovedSize))
+		}
+		fmt.Fprintf(c.stdout, "%s  %s  %-5s  %5d 项  %10s  %6.1fs%s\n",
+			record.ID, record.StartTime.Format("2006-01-02 15:04"), record.Kind,
+			record.TotalCount, utils.FormatSize(record.TotalSize),
+			float64(record.Duration)/1000, status)
+	}
+	return nil
+}
+
+// writeJSON 以 JSON 格式输出到 stdout
+func (c *CLI) writeJSON(v interface{}) error {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// sortItems 按路径排序，保证输出稳定
+func sortItems(items []models.ScanItem) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Path < items[j].Path
+	})
+}
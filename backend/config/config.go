@@ -2,16 +2,22 @@ package config
 
 import (
 	"encoding/json"
+	"fast-clean-x/backend/matcher"
 	"fast-clean-x/backend/models"
 	"fast-clean-x/backend/utils"
+	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Manager 配置管理器
 type Manager struct {
-	config *models.Config
-	mu     sync.RWMutex
+	config  *models.Config
+	notices []string // 加载配置时需要告知用户的变化，如自定义规则被重命名
+	mu      sync.RWMutex
 }
 
 var (
@@ -58,16 +64,28 @@ func (m *Manager) Load() error {
 	}
 
 	// 合并默认配置，确保新字段有默认值
-	m.config = m.mergeWithDefaults(&config)
+	m.config, m.notices = m.mergeWithDefaults(&config)
+	if len(m.notices) > 0 {
+		// 保存重命名后的规则，之后加载时不再重复提示
+		return m.saveInternal()
+	}
 	return nil
 }
 
+// Notices 返回加载配置时需要告知用户的变化
+func (m *Manager) Notices() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.notices...)
+}
+
 // mergeWithDefaults 将加载的配置与默认配置合并
 // 这样可以确保：
 // 1. 旧配置文件缺少的新字段会使用默认值
 // 2. 用户的数据（扫描路径、规则启用状态）会被保留
 // 3. 用户不需要手动删除配置文件
-func (m *Manager) mergeWithDefaults(loaded *models.Config) *models.Config {
+// 返回合并后的配置和需要告知用户的变化
+func (m *Manager) mergeWithDefaults(loaded *models.Config) (*models.Config, []string) {
 	defaults := models.DefaultConfig()
 
 	// 保留用户配置的路径和时间
@@ -75,24 +93,78 @@ func (m *Manager) mergeWithDefaults(loaded *models.Config) *models.Config {
 	defaults.IgnorePatterns = loaded.IgnorePatterns
 	defaults.LastScanTime = loaded.LastScanTime
 
+	// 旧配置没有受保护路径时使用空列表
+	if loaded.ProtectedPaths != nil {
+		defaults.ProtectedPaths = loaded.ProtectedPaths
+	}
+
+	// 旧配置没有删除方式时使用默认值
+	if loaded.DeleteMode != "" {
+		defaults.DeleteMode = loaded.DeleteMode
+	}
+	if loaded.SizeMode != "" {
+		defaults.SizeMode = loaded.SizeMode
+	}
+	if loaded.QuarantineDays > 0 {
+		defaults.QuarantineDays = loaded.QuarantineDays
+	}
+
 	// 如果旧配置有 GlobalPathExcludes，保留它；否则使用默认值
 	if len(loaded.GlobalPathExcludes) > 0 {
 		defaults.GlobalPathExcludes = loaded.GlobalPathExcludes
 	}
 
-	// 合并规则：保留用户的 enabled 状态，但使用默认的其他字段
+	// 合并规则：内置规则保留用户的 enabled 状态，但使用默认的其他字段
 	ruleEnabledMap := make(map[string]bool)
 	for _, rule := range loaded.ScanRules {
-		ruleEnabledMap[rule.Name] = rule.Enabled
+		if !rule.Custom {
+			ruleEnabledMap[rule.Name] = rule.Enabled
+		}
 	}
 
+	builtin := make(map[string]bool)
 	for i := range defaults.ScanRules {
+		builtin[strings.ToLower(defaults.ScanRules[i].Name)] = true
 		if enabled, exists := ruleEnabledMap[defaults.ScanRules[i].Name]; exists {
 			defaults.ScanRules[i].Enabled = enabled
 		}
 	}
 
-	return defaults
+	// 自定义规则原样保留，与新版本的内置规则重名时重命名后保留
+	// 不是自定义的未知规则是旧版本的内置规则，已经被移除或改名，直接丢弃
+	var notices []string
+	taken := make(map[string]bool)
+	for name := range builtin {
+		taken[name] = true
+	}
+	for _, rule := range loaded.ScanRules {
+		if rule.Custom {
+			taken[strings.ToLower(rule.Name)] = true
+		}
+	}
+	for _, rule := range loaded.ScanRules {
+		if !rule.Custom {
+			continue
+		}
+		if builtin[strings.ToLower(rule.Name)] {
+			name := renameRule(rule.Name, taken)
+			notices = append(notices, fmt.Sprintf("自定义规则 %s 与新的内置规则重名，已重命名为 %s", rule.Name, name))
+			rule.Name = name
+		}
+		defaults.ScanRules = append(defaults.ScanRules, rule)
+	}
+
+	return defaults, notices
+}
+
+// renameRule 为与内置规则重名的自定义规则生成不重复的新名称，taken 为已使用的名称（小写）
+func renameRule(name string, taken map[string]bool) string {
+	renamed := name + " (custom)"
+	for i := 2; taken[strings.ToLower(renamed)]; i++ {
+		renamed = fmt.Sprintf("%s (custom %d)", name, i)
+	}
+	taken[strings.ToLower(renamed)] = true
+	return renamed
 }
 
 // Save 保存配置到文件
@@ -127,8 +199,55 @@ func (m *Manager) GetConfig() *models.Config {
 	return &configCopy
 }
 
-// UpdateConfig 更新配置
+// UpdateConfig 更新配置，各项按与单独修改时相同的方式校验
+// 内置规则只能启用或禁用，不能修改定义或删除
 func (m *Manager) UpdateConfig(config *models.Config) error {
+	if err := validateDeleteMode(config.DeleteMode); err != nil {
+		return err
+	}
+	if err := validateSizeMode(config.SizeMode); err != nil {
+		return err
+	}
+	if err := validateQuarantineDays(config.QuarantineDays); err != nil {
+		return err
+	}
+	if config.CleanConcurrency < 0 {
+		return fmt.Errorf("clean concurrency must not be negative: %d", config.CleanConcurrency)
+	}
+
+	builtin := make(map[string]models.ScanRule)
+	for _, rule := range models.DefaultScanRules() {
+		builtin[strings.ToLower(rule.Name)] = rule
+	}
+	names := make(map[string]bool)
+	for i := range config.ScanRules {
+		config.ScanRules[i] = normalizeRule(config.ScanRules[i])
+		rule := config.ScanRules[i]
+		if err := validateRule(rule); err != nil {
+			return err
+		}
+		name := strings.ToLower(rule.Name)
+		if names[name] {
+			return fmt.Errorf("rule already exists: %s", rule.Name)
+		}
+		names[name] = true
+		if rule.Custom {
+			continue
+		}
+		def, ok := builtin[name]
+		if !ok {
+			return fmt.Errorf("unknown built-in rule: %s", rule.Name)
+		}
+		if !sameDefinition(rule, def) {
+			return fmt.Errorf("built-in rule cannot be modified: %s", rule.Name)
+		}
+	}
+	for name, def := range builtin {
+		if !names[name] {
+			return fmt.Errorf("built-in rule cannot be deleted, disable it instead: %s", def.Name)
+		}
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -168,6 +287,38 @@ func (m *Manager) RemoveScanPath(path string) error {
 	return m.saveInternal()
 }
 
+// AddProtectedPath 添加受保护的路径
+func (m *Manager) AddProtectedPath(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 检查是否已存在
+	for _, p := range m.config.ProtectedPaths {
+		if p == path {
+			return nil
+		}
+	}
+
+	m.config.ProtectedPaths = append(m.config.ProtectedPaths, path)
+	return m.saveInternal()
+}
+
+// RemoveProtectedPath 移除受保护的路径
+func (m *Manager) RemoveProtectedPath(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	newPaths := make([]string, 0)
+	for _, p := range m.config.ProtectedPaths {
+		if p != path {
+			newPaths = append(newPaths, p)
+		}
+	}
+
+	m.config.ProtectedPaths = newPaths
+	return m.saveInternal()
+}
+
 // AddIgnorePattern 添加忽略模式
 func (m *Manager) AddIgnorePattern(pattern string) error {
 	m.mu.Lock()
@@ -215,6 +366,281 @@ func (m *Manager) UpdateScanRule(ruleName string, enabled bool) error {
 	return nil
 }
 
+// AddScanRule 添加自定义扫描规则
+func (m *Manager) AddScanRule(rule models.ScanRule) error {
+	rule = normalizeRule(rule)
+	if err := validateRule(rule); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findRule(rule.Name) >= 0 {
+		return fmt.Errorf("rule already exists: %s", rule.Name)
+	}
+
+	rule.Custom = true
+	m.config.ScanRules = append(m.config.ScanRules, rule)
+	return m.saveInternal()
+}
+
+// UpdateScanRuleDefinition 修改自定义扫描规则的定义，可以同时修改名称
+// 内置规则只能启用或禁用（见 UpdateScanRule），不能修改定义
+func (m *Manager) UpdateScanRuleDefinition(ruleName string, rule models.ScanRule) error {
+	rule = normalizeRule(rule)
+	if err := validateRule(rule); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findRule(ruleName)
+	if i < 0 {
+		return fmt.Errorf("unknown rule: %s", ruleName)
+	}
+	if !m.config.ScanRules[i].Custom {
+		return fmt.Errorf("built-in rule cannot be modified: %s", ruleName)
+	}
+	if j := m.findRule(rule.Name); j >= 0 && j != i {
+		return fmt.Errorf("rule already exists: %s", rule.Name)
+	}
+
+	rule.Custom = true
+	m.config.ScanRules[i] = rule
+	return m.saveInternal()
+}
+
+// DeleteScanRule 删除自定义扫描规则，内置规则只能禁用
+func (m *Manager) DeleteScanRule(ruleName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findRule(ruleName)
+	if i < 0 {
+		return fmt.Errorf("unknown rule: %s", ruleName)
+	}
+	if !m.config.ScanRules[i].Custom {
+		return fmt.Errorf("built-in rule cannot be deleted, disable it instead: %s", ruleName)
+	}
+
+	rules := make([]models.ScanRule, 0, len(m.config.ScanRules)-1)
+	rules = append(rules, m.config.ScanRules[:i]...)
+	m.config.ScanRules = append(rules, m.config.ScanRules[i+1:]...)
+	return m.saveInternal()
+}
+
+// findRule 按名称（不区分大小写）查找规则，返回下标，找不到时返回 -1
+func (m *Manager) findRule(name string) int {
+	for i, rule := range m.config.ScanRules {
+		if strings.EqualFold(rule.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeRule 去掉规则中名称、目录和标识文件两端的空白
+func normalizeRule(rule models.ScanRule) models.ScanRule {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.TargetDirs = trimAll(rule.TargetDirs)
+	rule.ProjectMarkers = trimAll(rule.ProjectMarkers)
+	return rule
+}
+
+// sameDefinition 判断两条规则除启用状态外的定义是否相同，空列表与未设置视为相同
+func sameDefinition(a, b models.ScanRule) bool {
+	a, b = canonicalRule(a), canonicalRule(b)
+	a.Enabled = b.Enabled
+	return reflect.DeepEqual(a, b)
+}
+
+// canonicalRule 返回用于比较的规则副本，空列表统一为 nil
+func canonicalRule(rule models.ScanRule) models.ScanRule {
+	rule = normalizeRule(rule)
+	if len(rule.TargetDirs) == 0 {
+		rule.TargetDirs = nil
+	}
+	if len(rule.ProjectMarkers) == 0 {
+		rule.ProjectMarkers = nil
+	}
+	if len(rule.Verifiers) == 0 {
+		rule.Verifiers = nil
+		return rule
+	}
+	verifiers := make([]models.TargetVerifier, len(rule.Verifiers))
+	for i, verifier := range rule.Verifiers {
+		if len(verifier.Contains) == 0 {
+			verifier.Contains = nil
+		}
+		verifiers[i] = verifier
+	}
+	rule.Verifiers = verifiers
+	return rule
+}
+
+// trimAll 去掉每一项两端的空白，返回新的切片
+func trimAll(values []string) []string {
+	trimmed := make([]string, 0, len(values))
+	for _, value := range values {
+		trimmed = append(trimmed, strings.TrimSpace(value))
+	}
+	return trimmed
+}
+
+// validateRule 校验扫描规则的定义
+func validateRule(rule models.ScanRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if rule.Detector != "" && !matcher.IsDetector(rule.Detector) {
+		return fmt.Errorf("rule %s: unknown detector %q", rule.Name, rule.Detector)
+	}
+	if len(rule.TargetDirs) == 0 && rule.Detector == "" {
+		return fmt.Errorf("rule %s: at least one target directory is required", rule.Name)
+	}
+	for _, dir := range rule.TargetDirs {
+		if _, err := matcher.Compile(dir); err != nil {
+			return fmt.Errorf("rule %s: invalid target directory %q: %w", rule.Name, dir, err)
+		}
+	}
+	for _, marker := range rule.ProjectMarkers {
+		if err := validateName(marker); err != nil {
+			return fmt.Errorf("rule %s: invalid project marker %q: %w", rule.Name, marker, err)
+		}
+	}
+	if rule.RequireMarkers && len(rule.ProjectMarkers) == 0 {
+		return fmt.Errorf("rule %s: requireMarkers needs at least one project marker", rule.Name)
+	}
+	for _, verifier := range rule.Verifiers {
+		if !containsString(rule.TargetDirs, verifier.Target) {
+			return fmt.Errorf("rule %s: verifier target %q is not one of the target directories", rule.Name, verifier.Target)
+		}
+		if len(verifier.Contains) == 0 {
+			return fmt.Errorf("rule %s: verifier for %q needs at least one entry", rule.Name, verifier.Target)
+		}
+		for _, entry := range verifier.Contains {
+			for _, segment := range strings.Split(entry, "/") {
+				if err := validateName(segment); err != nil {
+					return fmt.Errorf("rule %s: invalid verifier entry %q: %w", rule.Name, entry, err)
+				}
+			}
+		}
+	}
+	switch rule.MarkerScope {
+	case "", models.MarkerScopeAncestor, models.MarkerScopeSibling, models.MarkerScopeWithin, models.MarkerScopeDeclared:
+	default:
+		return fmt.Errorf("rule %s: unknown marker scope: %s", rule.Name, rule.MarkerScope)
+	}
+	if rule.MarkerDepth < 0 || rule.MarkerDepth > 10 {
+		return fmt.Errorf("rule %s: marker depth must be between 0 and 10", rule.Name)
+	}
+	if rule.Priority < 0 {
+		return fmt.Errorf("rule %s: priority must not be negative", rule.Name)
+	}
+	return nil
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validateName 校验目录名或文件名：不能为空，不能包含路径分隔符，也不能是 . 或 ..
+func validateName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("empty name")
+	case name == "." || name == "..":
+		return fmt.Errorf("relative path element")
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("must be a single name without path separators")
+	}
+	return nil
+}
+
+// SetDeleteMode 设置默认删除方式
+func (m *Manager) SetDeleteMode(mode string) error {
+	if err := validateDeleteMode(mode); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.config.DeleteMode = mode
+	return m.saveInternal()
+}
+
+// SetSizeMode 设置大小统计方式
+func (m *Manager) SetSizeMode(mode string) error {
+	if err := validateSizeMode(mode); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.config.SizeMode = mode
+	return m.saveInternal()
+}
+
+// SetQuarantineDays 设置隔离区保留天数
+func (m *Manager) SetQuarantineDays(days int) error {
+	if err := validateQuarantineDays(days); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.config.QuarantineDays = days
+	return m.saveInternal()
+}
+
+// validateDeleteMode 校验删除方式
+func validateDeleteMode(mode string) error {
+	switch mode {
+	case models.DeleteModePermanent, models.DeleteModeTrash, models.DeleteModeQuarantine:
+		return nil
+	default:
+		return fmt.Errorf("unknown delete mode: %s", mode)
+	}
+}
+
+// validateSizeMode 校验大小统计方式
+func validateSizeMode(mode string) error {
+	switch mode {
+	case models.SizeModeApparent, models.SizeModeDisk:
+		return nil
+	default:
+		return fmt.Errorf("unknown size mode: %s", mode)
+	}
+}
+
+// validateQuarantineDays 校验隔离区保留天数
+func validateQuarantineDays(days int) error {
+	if days <= 0 {
+		return fmt.Errorf("quarantine days must be positive: %d", days)
+	}
+	return nil
+}
+
+// SetLastScanTime 记录上次扫描时间
+func (m *Manager) SetLastScanTime(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.config.LastScanTime = t
+	return m.saveInternal()
+}
+
 // GetEnabledRules 获取启用的扫描规则
 func (m *Manager) GetEnabledRules() []models.ScanRule {
 	m.mu.RLock()
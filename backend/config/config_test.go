@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fast-clean-x/backend/models"
+	"testing"
+)
+
+func TestMergeWithDefaultsKeepsCustomRules(t *testing.T) {
+	custom := models.ScanRule{Name: "Buck", TargetDirs: []string{"buck-out"}, Enabled: true, Custom: true}
+	loaded := &models.Config{ScanRules: []models.ScanRule{
+		{Name: "Maven", TargetDirs: []string{"old-target"}, Enabled: false},
+		{Name: "Removed", TargetDirs: []string{"gone"}, Enabled: true},
+		{Name: "node.js", TargetDirs: []string{"shadow"}, Enabled: false, Custom: true},
+		custom,
+	}}
+
+	merged, notices := (&Manager{}).mergeWithDefaults(loaded)
+	rules := make(map[string]models.ScanRule)
+	for _, rule := range merged.ScanRules {
+		rules[rule.Name] = rule
+	}
+
+	// 内置规则升级为新的定义，保留启用状态
+	maven := rules["Maven"]
+	if maven.Enabled || len(maven.TargetDirs) != 1 || maven.TargetDirs[0] != "target" {
+		t.Errorf("Maven = %+v, want upgraded definition and disabled", maven)
+	}
+	if _, ok := rules["Removed"]; ok {
+		t.Error("unknown built-in rule was kept")
+	}
+	// 与内置规则重名的自定义规则重命名后保留，不影响内置规则的启用状态
+	if got, ok := rules["node.js (custom)"]; !ok || !got.Custom || got.TargetDirs[0] != "shadow" {
+		t.Errorf("custom rule shadowing a built-in rule = %+v, want it renamed", got)
+	}
+	if !rules["Node.js"].Enabled {
+		t.Error("custom rule changed the enabled state of the built-in rule")
+	}
+	if len(notices) != 1 {
+		t.Errorf("notices = %v, want one for the renamed rule", notices)
+	}
+	if got, ok := rules["Buck"]; !ok || !got.Custom || got.TargetDirs[0] != "buck-out" {
+		t.Errorf("custom rule = %+v, want it kept unchanged", got)
+	}
+	if len(merged.ScanRules) != len(models.DefaultScanRules())+2 {
+		t.Errorf("got %d rules, want built-in rules plus two custom rules", len(merged.ScanRules))
+	}
+}
+
+func TestCustomRuleCRUD(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", t.TempDir())
+	m := &Manager{config: models.DefaultConfig()}
+
+	rule := models.ScanRule{Name: " Buck ", TargetDirs: []string{"buck-out"}, Enabled: true}
+	if err := m.AddScanRule(rule); err != nil {
+		t.Fatalf("AddScanRule() error: %v", err)
+	}
+	if err := m.AddScanRule(rule); err == nil {
+		t.Error("AddScanRule() accepted a duplicate name")
+	}
+
+	rule.Name = "Buck outputs"
+	rule.TargetDirs = []string{"buck-out", "buck-gen"}
+	if err := m.UpdateScanRuleDefinition("buck", rule); err != nil {
+		t.Fatalf("UpdateScanRuleDefinition() error: %v", err)
+	}
+	if i := m.findRule("Buck outputs"); i < 0 || !m.config.ScanRules[i].Custom || len(m.config.ScanRules[i].TargetDirs) != 2 {
+		t.Fatalf("rule was not updated: %+v", m.config.ScanRules)
+	}
+
+	if err := m.UpdateScanRuleDefinition("Maven", rule); err == nil {
+		t.Error("UpdateScanRuleDefinition() modified a built-in rule")
+	}
+	if err := m.DeleteScanRule("Maven"); err == nil {
+		t.Error("DeleteScanRule() deleted a built-in rule")
+	}
+	if err := m.DeleteScanRule("Buck outputs"); err != nil {
+		t.Fatalf("DeleteScanRule() error: %v", err)
+	}
+	if m.findRule("Buck outputs") >= 0 {
+		t.Error("rule was not deleted")
+	}
+}
+
+func TestUpdateConfigValidatesRules(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", t.TempDir())
+	m := &Manager{config: models.DefaultConfig()}
+
+	cfg := models.DefaultConfig()
+	cfg.ScanRules = append(cfg.ScanRules, models.ScanRule{Name: "Broken", TargetDirs: []string{"a/../b"}, Custom: true})
+	if err := m.UpdateConfig(cfg); err == nil {
+		t.Error("UpdateConfig() accepted an invalid rule")
+	}
+
+	cfg = models.DefaultConfig()
+	cfg.ScanRules = append(cfg.ScanRules, models.ScanRule{Name: "maven", TargetDirs: []string{"out"}, Custom: true})
+	if err := m.UpdateConfig(cfg); err == nil {
+		t.Error("UpdateConfig() accepted a duplicate rule name")
+	}
+	if len(m.config.ScanRules) != len(models.DefaultScanRules()) {
+		t.Error("rejected config was applied")
+	}
+
+	if err := m.UpdateConfig(models.DefaultConfig()); err != nil {
+		t.Errorf("UpdateConfig(defaults) error: %v", err)
+	}
+
+	cfg = models.DefaultConfig()
+	cfg.ScanRules[0].Enabled = !cfg.ScanRules[0].Enabled
+	cfg.ScanRules[0].Verifiers = []models.TargetVerifier{}
+	if err := m.UpdateConfig(cfg); err != nil {
+		t.Errorf("UpdateConfig() rejected toggling a built-in rule: %v", err)
+	}
+
+	cfg = models.DefaultConfig()
+	cfg.ScanRules[0].TargetDirs = append(cfg.ScanRules[0].TargetDirs, "src")
+	if err := m.UpdateConfig(cfg); err == nil {
+		t.Error("UpdateConfig() accepted a modified built-in rule")
+	}
+
+	cfg = models.DefaultConfig()
+	cfg.ScanRules = cfg.ScanRules[1:]
+	if err := m.UpdateConfig(cfg); err == nil {
+		t.Error("UpdateConfig() accepted a deleted built-in rule")
+	}
+}
+
+func TestUpdateConfigValidatesSettings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", t.TempDir())
+	m := &Manager{config: models.DefaultConfig()}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *models.Config)
+	}{
+		{"zero quarantine days", func(cfg *models.Config) { cfg.QuarantineDays = 0 }},
+		{"unknown delete mode", func(cfg *models.Config) { cfg.DeleteMode = "shred" }},
+		{"unknown size mode", func(cfg *models.Config) { cfg.SizeMode = "" }},
+		{"negative concurrency", func(cfg *models.Config) { cfg.CleanConcurrency = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := models.DefaultConfig()
+			tt.modify(cfg)
+			if err := m.UpdateConfig(cfg); err == nil {
+				t.Error("UpdateConfig() accepted an invalid setting")
+			}
+		})
+	}
+	if m.config.QuarantineDays != models.DefaultConfig().QuarantineDays {
+		t.Error("rejected config was applied")
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		name string
+		rule models.ScanRule
+	}{
+		{"missing name", models.ScanRule{TargetDirs: []string{"out"}}},
+		{"no targets", models.ScanRule{Name: "A"}},
+		{"empty target", models.ScanRule{Name: "A", TargetDirs: []string{""}}},
+		{"parent target", models.ScanRule{Name: "A", TargetDirs: []string{".."}}},
+		{"absolute target", models.ScanRule{Name: "A", TargetDirs: []string{"/tmp/out"}}},
+		{"relative segment", models.ScanRule{Name: "A", TargetDirs: []string{"bin/../obj"}}},
+		{"bad glob", models.ScanRule{Name: "A", TargetDirs: []string{"out["}}},
+		{"path marker", models.ScanRule{Name: "A", TargetDirs: []string{"out"}, ProjectMarkers: []string{"a/b"}}},
+		{"markers required", models.ScanRule{Name: "A", TargetDirs: []string{"out"}, RequireMarkers: true}},
+		{"verifier for unknown target", models.ScanRule{Name: "A", TargetDirs: []string{"out"},
+			Verifiers: []models.TargetVerifier{{Target: "dist", Contains: []string{"x"}}}}},
+		{"empty verifier", models.ScanRule{Name: "A", TargetDirs: []string{"out"},
+			Verifiers: []models.TargetVerifier{{Target: "out"}}}},
+		{"verifier escapes target", models.ScanRule{Name: "A", TargetDirs: []string{"out"},
+			Verifiers: []models.TargetVerifier{{Target: "out", Contains: []string{"../x"}}}}},
+		{"unknown detector", models.ScanRule{Name: "A", Detector: "nope"}},
+		{"negative priority", models.ScanRule{Name: "A", TargetDirs: []string{"out"}, Priority: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRule(normalizeRule(tt.rule)); err == nil {
+				t.Errorf("validateRule(%+v) = nil, want error", tt.rule)
+			}
+		})
+	}
+
+	valid := models.ScanRule{Name: "A", TargetDirs: []string{"out", "cmake-build-*", "bin/Debug", "build/**/tmp"},
+		ProjectMarkers: []string{"a.toml"}, RequireMarkers: true}
+	if err := validateRule(valid); err != nil {
+		t.Errorf("validateRule(valid) error: %v", err)
+	}
+	detector := models.ScanRule{Name: "B", Detector: models.DetectorCacheDirTag}
+	if err := validateRule(detector); err != nil {
+		t.Errorf("validateRule(detector) error: %v", err)
+	}
+	for _, rule := range models.DefaultScanRules() {
+		if err := validateRule(rule); err != nil {
+			t.Errorf("built-in rule %s is invalid: %v", rule.Name, err)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package globalcache
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime 返回文件的最后访问时间
+func accessTime(info os.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(st.Atim.Sec), int64(st.Atim.Nsec))
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package globalcache
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime 无法获取访问时间时使用修改时间
+func accessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,346 @@
+package globalcache
+
+import (
+	"encoding/xml"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Definition 一种开发工具的全局缓存
+type Definition struct {
+	ID          string
+	Name        string
+	Description string
+	Tools       []string // 工具命令，使用第一个能在 PATH 中找到的
+	PruneArgs   []string // 工具自带的清理不再使用的条目的命令参数，为空表示没有
+	ClearArgs   []string // 工具自带的清空缓存的命令参数，为空表示没有
+
+	// locate 返回候选的缓存目录，使用第一个存在的，都不存在时使用第一个
+	locate func(home string) []string
+	// toolEnv 返回让工具命令作用于该缓存目录的环境变量，保证清理的就是列出的目录
+	toolEnv func(path string) []string
+	// units 返回可以单独删除的缓存条目，为 nil 表示不支持模拟 prune
+	units func(root string) ([]string, error)
+}
+
+// Definitions 返回所有已知的全局缓存
+func Definitions() []Definition {
+	return []Definition{
+		{
+			ID:          "maven",
+			Name:        "Maven",
+			Description: "Maven 本地仓库",
+			locate:      mavenRepository,
+			units:       pomDirs,
+		},
+		{
+			ID:          "gradle",
+			Name:        "Gradle",
+			Description: "Gradle 依赖、转换和构建缓存",
+			locate: func(home string) []string {
+				return []string{filepath.Join(envOr("GRADLE_USER_HOME", filepath.Join(home, ".gradle")), "caches")}
+			},
+			units: globUnits(
+				"modules-2/files-2.1/*/*/*", // group/module/version
+				"transforms-*/*",
+				"build-cache-*/*",
+				"[0-9]*.*", // 旧版本 Gradle 的缓存，如 7.6
+			),
+		},
+		{
+			ID:          "npm",
+			Name:        "npm",
+			Description: "npm 下载缓存",
+			Tools:       []string{"npm"},
+			PruneArgs:   []string{"cache", "verify"},
+			ClearArgs:   []string{"cache", "clean", "--force"},
+			locate: func(home string) []string {
+				root := filepath.Join(home, ".npm")
+				if runtime.GOOS == "windows" {
+					root = filepath.Join(localAppData(home), "npm-cache")
+				}
+				if dir := envOr("npm_config_cache", os.Getenv("NPM_CONFIG_CACHE")); dir != "" {
+					root = dir
+				}
+				return []string{filepath.Join(root, "_cacache")}
+			},
+			toolEnv: func(path string) []string {
+				return []string{"npm_config_cache=" + filepath.Dir(path)}
+			},
+		},
+		{
+			ID:          "pip",
+			Name:        "pip",
+			Description: "pip 下载和 wheel 缓存",
+			Tools:       []string{"pip", "pip3"},
+			ClearArgs:   []string{"cache", "purge"},
+			locate: func(home string) []string {
+				if runtime.GOOS == "windows" {
+					return []string{envOr("PIP_CACHE_DIR", filepath.Join(localAppData(home), "pip", "Cache"))}
+				}
+				return []string{envOr("PIP_CACHE_DIR", filepath.Join(userCacheDir(home), "pip"))}
+			},
+			toolEnv: func(path string) []string {
+				return []string{"PIP_CACHE_DIR=" + path}
+			},
+			units: globUnits("http/*/*/*/*/*", "http-v2/*/*/*/*/*", "wheels/*/*/*/*/*"),
+		},
+		{
+			ID:          "cargo",
+			Name:        "Cargo",
+			Description: "Cargo 下载的 crate 和解压的源码",
+			locate: func(home string) []string {
+				return []string{filepath.Join(envOr("CARGO_HOME", filepath.Join(home, ".cargo")), "registry")}
+			},
+			units: globUnits("cache/*/*", "src/*/*"),
+		},
+		{
+			ID:          "go",
+			Name:        "Go",
+			Description: "Go 模块缓存",
+			Tools:       []string{"go"},
+			ClearArgs:   []string{"clean", "-modcache"},
+			locate: func(home string) []string {
+				if dir := os.Getenv("GOMODCACHE"); dir != "" {
+					return []string{dir}
+				}
+				if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+					return []string{filepath.Join(gopath[0], "pkg", "mod")}
+				}
+				return []string{filepath.Join(home, "go", "pkg", "mod")}
+			},
+			toolEnv: func(path string) []string {
+				return []string{"GOMODCACHE=" + path}
+			},
+		},
+		{
+			ID:          "yarn",
+			Name:        "Yarn",
+			Description: "Yarn 1.x 全局缓存",
+			Tools:       []string{"yarn"},
+			ClearArgs:   []string{"cache", "clean"},
+			locate: func(home string) []string {
+				switch runtime.GOOS {
+				case "windows":
+					return []string{envOr("YARN_CACHE_FOLDER", filepath.Join(localAppData(home), "Yarn", "Cache"))}
+				case "darwin":
+					return []string{envOr("YARN_CACHE_FOLDER", filepath.Join(home, "Library", "Caches", "Yarn"))}
+				default:
+					return []string{envOr("YARN_CACHE_FOLDER", filepath.Join(userCacheDir(home), "yarn"))}
+				}
+			},
+			toolEnv: func(path string) []string {
+				return []string{"YARN_CACHE_FOLDER=" + path}
+			},
+			units: globUnits("v*/*"),
+		},
+		{
+			ID:          "pnpm",
+			Name:        "pnpm",
+			Description: "pnpm 内容寻址存储",
+			Tools:       []string{"pnpm"},
+			PruneArgs:   []string{"store", "prune"},
+			locate: func(home string) []string {
+				if dir := os.Getenv("npm_config_store_dir"); dir != "" {
+					return []string{dir}
+				}
+				var data string
+				switch runtime.GOOS {
+				case "windows":
+					data = filepath.Join(localAppData(home), "pnpm")
+				case "darwin":
+					data = filepath.Join(home, "Library", "pnpm")
+				default:
+					data = filepath.Join(envOr("XDG_DATA_HOME", filepath.Join(home, ".local", "share")), "pnpm")
+				}
+				return []string{filepath.Join(home, ".pnpm-store"), filepath.Join(data, "store")}
+			},
+			toolEnv: func(path string) []string {
+				return []string{"npm_config_store_dir=" + path}
+			},
+		},
+		{
+			// bazel clean --expunge 只能在工作区中清理该工作区的输出，因此直接删除
+			ID:          "bazel",
+			Name:        "Bazel",
+			Description: "Bazel 各工作区的构建输出（bazel-* 符号链接指向的位置）、安装目录和仓库缓存",
+			locate:      bazelOutputRoot,
+			units:       bazelUnits,
+		},
+	}
+}
+
+// find 按标识查找全局缓存定义
+func find(id string) (Definition, bool) {
+	for _, def := range Definitions() {
+		if def.ID == id {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// resolve 返回缓存目录及其是否存在
+func (d Definition) resolve(home string) (string, bool) {
+	candidates := d.locate(home)
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return filepath.Clean(candidate), true
+		}
+	}
+	return filepath.Clean(candidates[0]), false
+}
+
+// envOr 返回环境变量的值，未设置时返回默认值
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// userCacheDir 返回用户缓存目录，Linux 上为 $XDG_CACHE_HOME 或 ~/.cache
+func userCacheDir(home string) string {
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library", "Caches")
+	}
+	return envOr("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+}
+
+// localAppData 返回 Windows 的 %LocalAppData%
+func localAppData(home string) string {
+	return envOr("LOCALAPPDATA", filepath.Join(home, "AppData", "Local"))
+}
+
+// bazelOutputRoot 返回 Bazel 默认的 output_user_root，其中每个工作区有一个以路径 MD5 命名的 output base
+func bazelOutputRoot(home string) []string {
+	name := "_bazel_" + bazelUser(home)
+	switch runtime.GOOS {
+	case "windows":
+		return []string{filepath.Join(home, name)}
+	case "darwin":
+		return []string{filepath.Join("/private/var/tmp", name)}
+	default:
+		candidates := []string{filepath.Join(home, ".cache", "bazel", name)}
+		if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+			candidates = append([]string{filepath.Join(dir, "bazel", name)}, candidates...)
+		}
+		return candidates
+	}
+}
+
+// bazelUser 返回 Bazel 用于 output_user_root 的用户名，与 Bazel 相同优先使用环境变量
+func bazelUser(home string) string {
+	for _, name := range []string{"USER", "USERNAME"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		// Windows 上为 DOMAIN\user
+		return u.Username[strings.LastIndex(u.Username, `\`)+1:]
+	}
+	return filepath.Base(home)
+}
+
+// bazelUnits 返回各工作区的 output base、各版本的 install base 和仓库缓存中的条目
+// output base 和 install base 都以 32 位十六进制的 MD5 命名，不会与 install、cache 等目录混淆
+func bazelUnits(root string) ([]string, error) {
+	var units []string
+	for _, dir := range []string{root, filepath.Join(root, "install")} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && isMD5(entry.Name()) {
+				units = append(units, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	repos, err := globUnits("cache/repos/v1/content_addressable/*/*")(root)
+	return append(units, repos...), err
+}
+
+// isMD5 判断名称是否为 32 位十六进制数
+func isMD5(name string) bool {
+	if len(name) != 32 {
+		return false
+	}
+	for _, c := range name {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// mavenRepository 读取 ~/.m2/settings.xml 中的 <localRepository>，未设置时使用 ~/.m2/repository
+func mavenRepository(home string) []string {
+	fallback := []string{filepath.Join(home, ".m2", "repository")}
+	data, err := os.ReadFile(filepath.Join(home, ".m2", "settings.xml"))
+	if err != nil {
+		return fallback
+	}
+
+	var settings struct {
+		LocalRepository string `xml:"localRepository"`
+	}
+	if err := xml.Unmarshal(data, &settings); err != nil {
+		return fallback
+	}
+	dir := strings.ReplaceAll(strings.TrimSpace(settings.LocalRepository), "${user.home}", home)
+	if dir == "" || strings.Contains(dir, "${") || !filepath.IsAbs(dir) {
+		return fallback
+	}
+	return []string{dir}
+}
+
+// pomDirs 返回 Maven 仓库中的版本目录（直接包含 .pom 文件的目录）
+// groupId 的层数不固定，无法用固定层数的模式表示
+func pomDirs(root string) ([]string, error) {
+	var units []string
+	err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			// 忽略无法读取的目录
+			return nil
+		}
+		if strings.HasSuffix(entry.Name(), ".pom") {
+			units = append(units, filepath.Dir(path))
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return units, err
+}
+
+// globUnits 返回按 / 分隔的模式匹配的缓存条目，每段支持 filepath.Match 通配符
+// 逐层读取目录而不是使用 filepath.Glob，缓存目录中的通配符不会被当作模式
+func globUnits(patterns ...string) func(root string) ([]string, error) {
+	return func(root string) ([]string, error) {
+		var units []string
+		for _, pattern := range patterns {
+			matches := []string{root}
+			for _, segment := range strings.Split(pattern, "/") {
+				var next []string
+				for _, dir := range matches {
+					entries, err := os.ReadDir(dir)
+					if err != nil {
+						continue
+					}
+					for _, entry := range entries {
+						if ok, _ := filepath.Match(segment, entry.Name()); ok {
+							next = append(next, filepath.Join(dir, entry.Name()))
+						}
+					}
+				}
+				matches = next
+			}
+			units = append(units, matches...)
+		}
+		return units, nil
+	}
+}
@@ -0,0 +1,335 @@
+// Package globalcache 统计和清理开发工具在项目之外的全局缓存，如 ~/.m2/repository、~/go/pkg/mod
+package globalcache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxAgeDays 模拟 prune 时默认清理多少天内没有使用的条目
+const DefaultMaxAgeDays = 90
+
+// maxOutput 保留的工具命令输出的最大长度
+const maxOutput = 4096
+
+// List 列出所有已知的全局缓存及其大小，缓存目录不存在时 Exists 为 false
+func List(ctx context.Context, sizeMode string) ([]models.GlobalCache, error) {
+	home, err := utils.GetHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	defs := Definitions()
+	caches := make([]models.GlobalCache, len(defs))
+	var wg sync.WaitGroup
+	for i, def := range defs {
+		caches[i] = describe(def, home)
+		if !caches[i].Exists {
+			continue
+		}
+
+		wg.Add(1)
+		go func(cache *models.GlobalCache) {
+			defer wg.Done()
+			usage, err := utils.MeasureDirContext(ctx, cache.Path)
+			if err != nil {
+				cache.Error = err.Error()
+			}
+			cache.Size = usage.Size(sizeMode)
+			cache.ApparentSize = usage.ApparentSize
+			cache.DiskSize = usage.DiskSize
+			cache.SizeReadable = utils.FormatSize(cache.Size)
+			cache.FileCount = usage.FileCount
+		}(&caches[i])
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return caches, err
+	}
+	return caches, nil
+}
+
+// describe 返回缓存的位置和支持的清理方式，不统计大小
+func describe(def Definition, home string) models.GlobalCache {
+	path, exists := def.resolve(home)
+	cache := models.GlobalCache{
+		ID:          def.ID,
+		Name:        def.Name,
+		Description: def.Description,
+		Path:        path,
+		Exists:      exists,
+		Tool:        lookTool(def.Tools),
+		CanPrune:    def.units != nil,
+	}
+	if cache.Tool != "" {
+		if len(def.PruneArgs) > 0 {
+			cache.PruneCommand = commandLine(cache.Tool, def.PruneArgs)
+			cache.CanPrune = true
+		}
+		if len(def.ClearArgs) > 0 {
+			cache.ClearCommand = commandLine(cache.Tool, def.ClearArgs)
+		}
+	}
+	return cache
+}
+
+// Clean 清理全局缓存
+//
+// prune 优先调用工具自带的命令，没有时删除 MaxAgeDays 天内没有使用（修改或访问）的缓存条目；
+// clear 优先调用工具自带的命令，没有时删除缓存目录中的所有内容，缓存目录本身保留。
+// protected 为用户定义的受保护路径，与缓存目录重叠时拒绝清理。
+func Clean(ctx context.Context, id string, opts models.GlobalCacheCleanOptions, sizeMode string, protected []string) (*models.GlobalCacheCleanResult, error) {
+	def, ok := find(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown global cache: %q", id)
+	}
+	if opts.Mode == "" {
+		opts.Mode = models.GlobalCachePrune
+	}
+	if opts.Mode != models.GlobalCachePrune && opts.Mode != models.GlobalCacheClear {
+		return nil, fmt.Errorf("invalid clean mode: %q", opts.Mode)
+	}
+	if opts.MaxAgeDays <= 0 {
+		opts.MaxAgeDays = DefaultMaxAgeDays
+	}
+
+	home, err := utils.GetHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	path, exists := def.resolve(home)
+	if !exists {
+		return nil, fmt.Errorf("cache directory does not exist: %s", path)
+	}
+	if err := checkPath(path, home, protected); err != nil {
+		return nil, err
+	}
+
+	tool := ""
+	if !opts.NoTool {
+		tool = lookTool(def.Tools)
+	}
+
+	start := time.Now()
+	result := &models.GlobalCacheCleanResult{ID: def.ID, Path: path, Mode: opts.Mode, DryRun: opts.DryRun}
+	switch {
+	case opts.Mode == models.GlobalCachePrune && tool != "" && len(def.PruneArgs) > 0:
+		err = runTool(ctx, def, tool, def.PruneArgs, path, sizeMode, result)
+	case opts.Mode == models.GlobalCachePrune && def.units != nil:
+		err = emulatePrune(ctx, def, path, opts.MaxAgeDays, sizeMode, result)
+	case opts.Mode == models.GlobalCachePrune:
+		if len(def.PruneArgs) > 0 {
+			return nil, fmt.Errorf("%s cache can only be pruned by %s, which was not found", def.Name, def.Tools[0])
+		}
+		return nil, fmt.Errorf("%s cache does not support pruning, use clear instead", def.Name)
+	case tool != "" && len(def.ClearArgs) > 0:
+		err = runTool(ctx, def, tool, def.ClearArgs, path, sizeMode, result)
+	default:
+		err = clearDir(ctx, path, sizeMode, result)
+	}
+
+	result.FreedReadable = utils.FormatSize(result.FreedSize)
+	result.Duration = time.Since(start).Milliseconds()
+	return result, err
+}
+
+// checkPath 检查缓存目录是否可以清理：不能是文件系统根目录、主目录或其上级目录，
+// 不能是符号链接，也不能与用户定义的受保护路径重叠
+// 缓存目录可以位于 ~/Library 等扫描时跳过的系统目录中，因此不使用 cleaner.Protector
+func checkPath(path, home string, protected []string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat cache directory: %w", err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("refusing to clean %s: it is a symbolic link", path)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("cache path is not a directory: %s", path)
+	}
+	if filepath.Dir(path) == path || path == home || utils.IsUnder(home, path) {
+		return fmt.Errorf("refusing to clean %s: it is a filesystem root or contains the home directory", path)
+	}
+	for _, p := range protected {
+		if p == "" {
+			continue
+		}
+		p = filepath.Clean(p)
+		if path == p || utils.IsUnder(path, p) || utils.IsUnder(p, path) {
+			return fmt.Errorf("refusing to clean %s: it overlaps protected path %s", path, p)
+		}
+	}
+	return nil
+}
+
+// runTool 调用工具自带的命令，通过环境变量让工具作用于列出的缓存目录
+// 释放的大小为命令执行前后缓存目录大小的差值，模拟时不执行命令
+func runTool(ctx context.Context, def Definition, tool string, args []string, path, sizeMode string, result *models.GlobalCacheCleanResult) error {
+	result.Method = models.GlobalCacheMethodTool
+	result.Command = commandLine(tool, args)
+
+	before, err := utils.MeasureDirContext(ctx, path)
+	if err != nil {
+		return err
+	}
+	if result.DryRun {
+		// 无法预知工具会删除多少，clear 按清空整个目录估计
+		if result.Mode == models.GlobalCacheClear {
+			result.FreedSize = before.Size(sizeMode)
+		}
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.Dir = filepath.Dir(path)
+	cmd.Env = os.Environ()
+	if def.toolEnv != nil {
+		cmd.Env = append(cmd.Env, def.toolEnv(path)...)
+	}
+	output, runErr := cmd.CombinedOutput()
+	result.Output = tail(output, maxOutput)
+
+	after, err := utils.MeasureDirContext(ctx, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		after, err = utils.DirUsage{}, nil
+	}
+	if err == nil {
+		result.FreedSize = max(before.Size(sizeMode)-after.Size(sizeMode), 0)
+	}
+	if runErr != nil {
+		return fmt.Errorf("%s failed: %w", result.Command, runErr)
+	}
+	return err
+}
+
+// emulatePrune 删除 maxAgeDays 天内没有修改或访问过的缓存条目，并删除因此变空的上级目录
+func emulatePrune(ctx context.Context, def Definition, path string, maxAgeDays int, sizeMode string, result *models.GlobalCacheCleanResult) error {
+	result.Method = models.GlobalCacheMethodEmulate
+
+	units, err := def.units(path)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -maxAgeDays)
+	for _, unit := range units {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		usage, lastUsed, err := inspect(unit)
+		if err != nil || lastUsed.After(cutoff) {
+			continue
+		}
+
+		if !result.DryRun {
+			if err := utils.RemoveAll(unit); err != nil {
+				return err
+			}
+			removeEmptyParents(unit, path)
+		}
+		result.RemovedCount++
+		result.FreedSize += usage.Size(sizeMode)
+	}
+	return nil
+}
+
+// inspect 统计缓存条目的大小和最后使用时间（其中文件最晚的修改或访问时间）
+func inspect(path string) (utils.DirUsage, time.Time, error) {
+	var counter utils.UsageCounter
+	var lastUsed time.Time
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		counter.Add(info)
+		if !info.IsDir() {
+			lastUsed = latest(lastUsed, info.ModTime(), accessTime(info))
+		}
+		return nil
+	})
+	return counter.Usage, lastUsed, err
+}
+
+// clearDir 删除缓存目录中的所有内容，保留缓存目录本身
+func clearDir(ctx context.Context, path, sizeMode string, result *models.GlobalCacheCleanResult) error {
+	result.Method = models.GlobalCacheMethodDelete
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entryPath := filepath.Join(path, entry.Name())
+		usage, err := utils.MeasureDirContext(ctx, entryPath)
+		if err != nil {
+			return err
+		}
+		if !result.DryRun {
+			if err := utils.RemoveAll(entryPath); err != nil {
+				return err
+			}
+		}
+		result.RemovedCount++
+		result.FreedSize += usage.Size(sizeMode)
+	}
+	return nil
+}
+
+// removeEmptyParents 删除 path 的上级目录中变空的目录，直到 root（不包括 root）
+func removeEmptyParents(path, root string) {
+	for dir := filepath.Dir(path); utils.IsUnder(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// lookTool 返回第一个能在 PATH 中找到的工具命令，都找不到时返回空字符串
+func lookTool(tools []string) string {
+	for _, tool := range tools {
+		if path, err := exec.LookPath(tool); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// commandLine 返回用于展示的命令行
+func commandLine(tool string, args []string) string {
+	return strings.Join(append([]string{filepath.Base(tool)}, args...), " ")
+}
+
+// tail 返回输出末尾最多 n 个字节
+func tail(output []byte, n int) string {
+	output = bytes.TrimSpace(output)
+	if len(output) > n {
+		output = output[len(output)-n:]
+	}
+	return string(output)
+}
+
+// latest 返回最晚的时间
+func latest(times ...time.Time) time.Time {
+	var t time.Time
+	for _, candidate := range times {
+		if candidate.After(t) {
+			t = candidate
+		}
+	}
+	return t
+}
@@ -0,0 +1,269 @@
+package globalcache
+
+import (
+	"context"
+	"fast-clean-x/backend/models"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupHome 使用临时主目录，清除影响缓存位置的环境变量，PATH 中没有任何工具
+func setupHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	for _, name := range []string{
+		"XDG_CACHE_HOME", "XDG_DATA_HOME", "LOCALAPPDATA", "GRADLE_USER_HOME", "npm_config_cache", "NPM_CONFIG_CACHE",
+		"PIP_CACHE_DIR", "CARGO_HOME", "GOMODCACHE", "GOPATH", "YARN_CACHE_FOLDER", "npm_config_store_dir",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("PATH", t.TempDir())
+	return home
+}
+
+// writeFile 创建指定大小的文件及其父目录
+func writeFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// age 将目录中所有文件的修改和访问时间设置为 days 天前
+func age(t *testing.T, dir string, days int) {
+	t.Helper()
+	old := time.Now().AddDate(0, 0, -days)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, old, old)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	home := setupHome(t)
+	path := func(id string) string {
+		def, ok := find(id)
+		if !ok {
+			t.Fatalf("unknown cache %s", id)
+		}
+		p, _ := def.resolve(home)
+		return p
+	}
+
+	if got, want := path("maven"), filepath.Join(home, ".m2", "repository"); got != want {
+		t.Errorf("maven = %s, want %s", got, want)
+	}
+	if got, want := path("go"), filepath.Join(home, "go", "pkg", "mod"); got != want {
+		t.Errorf("go = %s, want %s", got, want)
+	}
+
+	// 环境变量和 Maven settings.xml 中的位置
+	writeFile(t, filepath.Join(home, ".m2", "settings.xml"), 0)
+	settings := `<settings><localRepository>${user.home}/m2repo</localRepository></settings>`
+	if err := os.WriteFile(filepath.Join(home, ".m2", "settings.xml"), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GRADLE_USER_HOME", filepath.Join(home, "gradle-home"))
+	t.Setenv("GOPATH", filepath.Join(home, "gopath"))
+	t.Setenv("CARGO_HOME", filepath.Join(home, "cargo-home"))
+
+	tests := map[string]string{
+		"maven":  filepath.Join(home, "m2repo"),
+		"gradle": filepath.Join(home, "gradle-home", "caches"),
+		"go":     filepath.Join(home, "gopath", "pkg", "mod"),
+		"cargo":  filepath.Join(home, "cargo-home", "registry"),
+	}
+	for id, want := range tests {
+		if got := path(id); got != want {
+			t.Errorf("%s = %s, want %s", id, got, want)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	home := setupHome(t)
+	writeFile(t, filepath.Join(home, ".m2", "repository", "junit", "junit", "4.13", "junit-4.13.jar"), 1000)
+
+	caches, err := List(context.Background(), models.SizeModeApparent)
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(caches) != len(Definitions()) {
+		t.Fatalf("List() returned %d caches, want %d", len(caches), len(Definitions()))
+	}
+	for _, cache := range caches {
+		switch cache.ID {
+		case "maven":
+			if !cache.Exists || cache.Size != 1000 || cache.FileCount != 1 || !cache.CanPrune {
+				t.Errorf("maven = %+v", cache)
+			}
+		case "npm":
+			// 没有 npm 时既不能 prune 也没有 clear 命令
+			if cache.Exists || cache.CanPrune || cache.ClearCommand != "" {
+				t.Errorf("npm = %+v", cache)
+			}
+		}
+	}
+}
+
+func TestPruneEmulated(t *testing.T) {
+	home := setupHome(t)
+	repo := filepath.Join(home, ".m2", "repository")
+	writeFile(t, filepath.Join(repo, "com", "old", "lib", "1.0", "lib-1.0.pom"), 10)
+	writeFile(t, filepath.Join(repo, "com", "old", "lib", "1.0", "lib-1.0.jar"), 1000)
+	writeFile(t, filepath.Join(repo, "com", "new", "lib", "2.0", "lib-2.0.pom"), 10)
+	writeFile(t, filepath.Join(repo, "com", "new", "lib", "2.0", "lib-2.0.jar"), 2000)
+	age(t, filepath.Join(repo, "com", "old"), 200)
+
+	opts := models.GlobalCacheCleanOptions{Mode: models.GlobalCachePrune, DryRun: true}
+	result, err := Clean(context.Background(), "maven", opts, models.SizeModeApparent, nil)
+	if err != nil {
+		t.Fatalf("Clean(dry run) error: %v", err)
+	}
+	if result.Method != models.GlobalCacheMethodEmulate || result.RemovedCount != 1 || result.FreedSize != 1010 {
+		t.Errorf("Clean(dry run) = %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "com", "old", "lib", "1.0")); err != nil {
+		t.Errorf("dry run removed files: %v", err)
+	}
+
+	opts.DryRun = false
+	if _, err := Clean(context.Background(), "maven", opts, models.SizeModeApparent, nil); err != nil {
+		t.Fatalf("Clean() error: %v", err)
+	}
+	// 变空的 groupId 目录一并删除
+	if _, err := os.Stat(filepath.Join(repo, "com", "old")); !os.IsNotExist(err) {
+		t.Errorf("stale artifact was not removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "com", "new", "lib", "2.0", "lib-2.0.jar")); err != nil {
+		t.Errorf("recent artifact was removed: %v", err)
+	}
+}
+
+func TestPruneBazel(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("output_user_root outside the home directory")
+	}
+	home := setupHome(t)
+	t.Setenv("USER", "dev")
+	root := filepath.Join(home, ".cache", "bazel", "_bazel_dev")
+	stale := filepath.Join(root, "0123456789abcdef0123456789abcdef")
+	active := filepath.Join(root, "fedcba9876543210fedcba9876543210")
+	install := filepath.Join(root, "install", "00112233445566778899aabbccddeeff")
+	writeFile(t, filepath.Join(stale, "execroot", "ws", "bazel-out", "lib.a"), 1000)
+	writeFile(t, filepath.Join(active, "execroot", "ws", "bazel-out", "lib.a"), 2000)
+	writeFile(t, filepath.Join(install, "A-server.jar"), 300)
+	writeFile(t, filepath.Join(root, "cache", "repos", "v1", "content_addressable", "sha256", "abc", "file"), 40)
+	age(t, stale, 200)
+	age(t, install, 200)
+	age(t, filepath.Join(root, "cache"), 200)
+	// Bazel 的构建输出是只读的
+	outputs := filepath.Join(stale, "execroot", "ws", "bazel-out")
+	os.Chmod(outputs, 0555)
+	t.Cleanup(func() { os.Chmod(outputs, 0755) })
+
+	result, err := Clean(context.Background(), "bazel", models.GlobalCacheCleanOptions{Mode: models.GlobalCachePrune}, models.SizeModeApparent, nil)
+	if err != nil {
+		t.Fatalf("Clean() error: %v", err)
+	}
+	if result.Method != models.GlobalCacheMethodEmulate || result.RemovedCount != 3 || result.FreedSize != 1340 {
+		t.Errorf("Clean() = %+v", result)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale output base was not removed: %v", err)
+	}
+	if _, err := os.Stat(active); err != nil {
+		t.Errorf("active output base was removed: %v", err)
+	}
+}
+
+func TestClearReadOnlyCache(t *testing.T) {
+	home := setupHome(t)
+	modcache := filepath.Join(home, "go", "pkg", "mod")
+	module := filepath.Join(modcache, "example.com", "mod@v1.0.0")
+	writeFile(t, filepath.Join(module, "go.mod"), 100)
+	// Go 模块缓存中的文件和目录是只读的
+	os.Chmod(filepath.Join(module, "go.mod"), 0444)
+	os.Chmod(module, 0555)
+	t.Cleanup(func() { os.Chmod(module, 0755) })
+
+	result, err := Clean(context.Background(), "go", models.GlobalCacheCleanOptions{Mode: models.GlobalCacheClear}, models.SizeModeApparent, nil)
+	if err != nil {
+		t.Fatalf("Clean() error: %v", err)
+	}
+	if result.Method != models.GlobalCacheMethodDelete || result.FreedSize != 100 {
+		t.Errorf("Clean() = %+v", result)
+	}
+	entries, err := os.ReadDir(modcache)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("cache directory after clear = %v, %v; want it empty but present", entries, err)
+	}
+}
+
+func TestCleanRefusesUnsafe(t *testing.T) {
+	home := setupHome(t)
+	writeFile(t, filepath.Join(home, "go", "pkg", "mod", "cache", "x"), 10)
+	clearOpts := models.GlobalCacheCleanOptions{Mode: models.GlobalCacheClear, DryRun: true}
+
+	if _, err := Clean(context.Background(), "unknown", clearOpts, "", nil); err == nil {
+		t.Error("unknown cache: error = nil")
+	}
+	// Go 模块缓存只能通过 go 命令 prune
+	if _, err := Clean(context.Background(), "go", models.GlobalCacheCleanOptions{}, "", nil); err == nil {
+		t.Error("prune without tool: error = nil")
+	}
+	if _, err := Clean(context.Background(), "go", clearOpts, "", []string{filepath.Join(home, "go")}); err == nil {
+		t.Error("protected cache: error = nil")
+	}
+	t.Setenv("GOMODCACHE", home)
+	if _, err := Clean(context.Background(), "go", clearOpts, "", nil); err == nil {
+		t.Error("cache at home directory: error = nil")
+	}
+	if _, err := Clean(context.Background(), "maven", clearOpts, "", nil); err == nil {
+		t.Error("missing cache: error = nil")
+	}
+}
+
+func TestCleanRunsTool(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script tools are not supported on Windows")
+	}
+	home := setupHome(t)
+	writeFile(t, filepath.Join(home, ".npm", "_cacache", "content-v2", "stale"), 500)
+	writeFile(t, filepath.Join(home, ".npm", "_cacache", "index-v5", "entry"), 10)
+
+	// 模拟 npm：记录参数和缓存位置，删除未被引用的内容
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho \"$@ $npm_config_cache\" > \"$HOME/npm-args\"\nrm -f \"$npm_config_cache/_cacache/content-v2/stale\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "npm"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 脚本本身需要 sh 和 rm，模拟的 npm 在 PATH 中排在前面
+	t.Setenv("PATH", strings.Join([]string{bin, "/bin", "/usr/bin"}, string(os.PathListSeparator)))
+
+	result, err := Clean(context.Background(), "npm", models.GlobalCacheCleanOptions{}, models.SizeModeApparent, nil)
+	if err != nil {
+		t.Fatalf("Clean() error: %v", err)
+	}
+	if result.Method != models.GlobalCacheMethodTool || result.Command != "npm cache verify" || result.FreedSize != 500 {
+		t.Errorf("Clean() = %+v", result)
+	}
+	args, _ := os.ReadFile(filepath.Join(home, "npm-args"))
+	if got := strings.TrimSpace(string(args)); got != "cache verify "+filepath.Join(home, ".npm") {
+		t.Errorf("npm was called with %q", got)
+	}
+}
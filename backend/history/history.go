@@ -0,0 +1,318 @@
+package history
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxRecords 最多保留的历史记录数量，超出后删除最旧的记录
+const MaxRecords = 1000
+
+// Store 历史记录存储，保存在配置目录下的 history.json
+type Store struct {
+	path string
+	mu   *sync.Mutex
+}
+
+// locks 每个历史记录文件一把锁，同一进程中使用同一文件的 Store 共用，
+// 扫描和清理同时保存记录时读取、修改、写入不会交错
+var locks sync.Map
+
+// New 创建使用指定文件的历史记录存储
+func New(path string) *Store {
+	mu, _ := locks.LoadOrStore(filepath.Clean(path), &sync.Mutex{})
+	return &Store{path: path, mu: mu.(*sync.Mutex)}
+}
+
+// Open 打开默认历史记录存储
+func Open() (*Store, error) {
+	configDir, err := utils.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(filepath.Join(configDir, "history.json")), nil
+}
+
+// Save 将记录添加到默认历史记录存储
+func Save(record models.HistoryRecord) error {
+	store, err := Open()
+	if err != nil {
+		return err
+	}
+	_, err = store.Add(record)
+	return err
+}
+
+// Add 添加一条历史记录，返回分配了 ID 的记录
+func (s *Store) Add(record models.HistoryRecord) (*models.HistoryRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if record.ID == "" {
+		record.ID, err = newID(record.StartTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+	records = append(records, record)
+
+	if len(records) > MaxRecords {
+		records = records[len(records)-MaxRecords:]
+	}
+
+	if err := s.save(records); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// List 查询历史记录，按开始时间倒序
+func (s *Store) List(filter models.HistoryFilter) ([]models.HistoryRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.HistoryRecord, 0, len(records))
+	for _, record := range records {
+		if filter.Kind != "" && record.Kind != filter.Kind {
+			continue
+		}
+		if !filter.Since.IsZero() && record.StartTime.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && record.StartTime.After(filter.Until) {
+			continue
+		}
+		result = append(result, record)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartTime.After(result[j].StartTime)
+	})
+	return result, nil
+}
+
+// Delete 删除指定的历史记录，返回实际删除的数量
+func (s *Store) Delete(ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	idSet := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		idSet[id] = true
+	}
+
+	kept := make([]models.HistoryRecord, 0, len(records))
+	for _, record := range records {
+		if !idSet[record.ID] {
+			kept = append(kept, record)
+		}
+	}
+
+	deleted := len(records) - len(kept)
+	if deleted == 0 {
+		return 0, nil
+	}
+	return deleted, s.save(kept)
+}
+
+// Clear 清空所有历史记录
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// load 读取所有记录，文件不存在时返回空列表
+func (s *Store) load() ([]models.HistoryRecord, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []models.HistoryRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []models.HistoryRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// save 写入所有记录，先写临时文件再重命名，避免写入中断导致文件损坏
+// 每次写入使用不同的临时文件，其他进程同时写入时不会写到同一个临时文件中
+func (s *Store) save(records []models.HistoryRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// newID 生成记录 ID
+func newID(t time.Time) (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return t.Format("20060102-150405") + "-" + hex.EncodeToString(buf), nil
+}
+
+// NewScanRecord 根据扫描结果生成历史记录
+func NewScanRecord(paths []string, result *models.ScanResult, startTime time.Time, scanErr error) models.HistoryRecord {
+	record := models.HistoryRecord{
+		Kind:      models.HistoryKindScan,
+		StartTime: startTime,
+		Duration:  time.Since(startTime).Milliseconds(),
+		ScanPaths: paths,
+		RuleStats: []models.RuleStat{},
+	}
+
+	if result != nil {
+		record.TotalCount = result.TotalCount
+		record.TotalSize = result.TotalSize
+		record.RuleStats = RuleStats(result.Items)
+	}
+	setError(&record, scanErr)
+	return record
+}
+
+// NewCleanRecord 根据清理结果生成历史记录
+func NewCleanRecord(result *models.CleanResult, deleteMode string, startTime time.Time, cleanErr error) models.HistoryRecord {
+	record := models.HistoryRecord{
+		Kind:       models.HistoryKindClean,
+		StartTime:  startTime,
+		Duration:   time.Since(startTime).Milliseconds(),
+		DeleteMode: deleteMode,
+		RuleStats:  []models.RuleStat{},
+	}
+
+	if result != nil {
+		for _, itemResult := range result.Items {
+			if itemResult.Status == models.CleanStatusFailed {
+				record.FailedItems = append(record.FailedItems, itemResult.Path)
+			}
+		}
+
+		record.TotalCount = result.CleanedCount
+		record.TotalSize = result.CleanedSize
+		record.MovedSize = result.MovedSize
+		record.RuleStats = cleanRuleStats(result.Items)
+	}
+	setError(&record, cleanErr)
+	return record
+}
+
+// RuleStats 按规则统计扫描项的数量和大小，按大小倒序
+func RuleStats(items []models.ScanItem) []models.RuleStat {
+	var stats ruleStats
+	for _, item := range items {
+		stats.add(item.Type, 1, item.Size)
+	}
+	return stats.sorted()
+}
+
+// cleanRuleStats 按规则统计清理结果，与 CleanResult 的统计方式一致：
+// 数量只包括成功清理的项目，大小为实际释放或移到回收站、隔离区的大小，包括失败的项目已经删除的部分
+func cleanRuleStats(items []models.CleanItemResult) []models.RuleStat {
+	var stats ruleStats
+	for _, item := range items {
+		size := item.FreedSize + item.MovedSize
+		switch {
+		case item.Status == models.CleanStatusSuccess:
+			stats.add(item.Type, 1, size)
+		case size > 0:
+			stats.add(item.Type, 0, size)
+		}
+	}
+	return stats.sorted()
+}
+
+// ruleStats 按规则累计数量和大小，保持规则首次出现的顺序
+type ruleStats struct {
+	index map[string]int
+	stats []models.RuleStat
+}
+
+// add 累计规则的数量和大小
+func (r *ruleStats) add(rule string, count int, size int64) {
+	if r.index == nil {
+		r.index = make(map[string]int)
+	}
+	i, exists := r.index[rule]
+	if !exists {
+		i = len(r.stats)
+		r.index[rule] = i
+		r.stats = append(r.stats, models.RuleStat{Rule: rule})
+	}
+	r.stats[i].Count += count
+	r.stats[i].Size += size
+}
+
+// sorted 返回按大小倒序的统计结果
+func (r *ruleStats) sorted() []models.RuleStat {
+	stats := append(make([]models.RuleStat, 0, len(r.stats)), r.stats...)
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Size > stats[j].Size
+	})
+	return stats
+}
+
+// setError 记录错误信息，取消不视为错误
+func setError(record *models.HistoryRecord, err error) {
+	if err == nil {
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		record.Cancelled = true
+		return
+	}
+	record.Error = err.Error()
+}
@@ -0,0 +1,126 @@
+package history
+
+import (
+	"context"
+	"fast-clean-x/backend/models"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStoreListAndDelete(t *testing.T) {
+	store := New(filepath.Join(t.TempDir(), "history.json"))
+	base := time.Date(2025, 11, 1, 10, 0, 0, 0, time.Local)
+
+	var ids []string
+	for i, kind := range []string{models.HistoryKindScan, models.HistoryKindClean, models.HistoryKindScan} {
+		record, err := store.Add(models.HistoryRecord{
+			Kind:      kind,
+			StartTime: base.AddDate(0, 0, i),
+		})
+		if err != nil {
+			t.Fatalf("Add() error: %v", err)
+		}
+		ids = append(ids, record.ID)
+	}
+
+	tests := []struct {
+		name     string
+		filter   models.HistoryFilter
+		expected []string
+	}{
+		{"全部记录按时间倒序", models.HistoryFilter{}, []string{ids[2], ids[1], ids[0]}},
+		{"按类型过滤", models.HistoryFilter{Kind: models.HistoryKindScan}, []string{ids[2], ids[0]}},
+		{"按开始时间过滤", models.HistoryFilter{Since: base.AddDate(0, 0, 1)}, []string{ids[2], ids[1]}},
+		{"按日期范围过滤", models.HistoryFilter{Since: base, Until: base.Add(time.Hour)}, []string{ids[0]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := store.List(tt.filter)
+			if err != nil {
+				t.Fatalf("List() error: %v", err)
+			}
+			if len(records) != len(tt.expected) {
+				t.Fatalf("List() returned %d records, want %d", len(records), len(tt.expected))
+			}
+			for i, record := range records {
+				if record.ID != tt.expected[i] {
+					t.Errorf("record[%d] = %s, want %s", i, record.ID, tt.expected[i])
+				}
+			}
+		})
+	}
+
+	if n, err := store.Delete([]string{ids[0], "missing"}); err != nil || n != 1 {
+		t.Fatalf("Delete() = %d, %v; want 1, nil", n, err)
+	}
+	if records, _ := store.List(models.HistoryFilter{}); len(records) != 2 {
+		t.Fatalf("List() after delete returned %d records, want 2", len(records))
+	}
+
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear() error: %v", err)
+	}
+	if records, _ := store.List(models.HistoryFilter{}); len(records) != 0 {
+		t.Fatalf("List() after clear returned %d records, want 0", len(records))
+	}
+}
+
+func TestNewCleanRecord(t *testing.T) {
+	result := &models.CleanResult{
+		Items: []models.CleanItemResult{
+			{Path: "/p/a/node_modules", Type: "Node.js", Status: models.CleanStatusSuccess, FreedSize: 100},
+			{Path: "/p/b/target", Type: "Maven", Status: models.CleanStatusFailed, FreedSize: 30},
+			{Path: "/p/c/node_modules", Type: "Node.js", Status: models.CleanStatusSkipped},
+			{Path: "/p/d/dist", Type: "Node.js", Status: models.CleanStatusSuccess, FreedSize: 20},
+			{Path: "/p/e/target", Type: "Maven", Status: models.CleanStatusCancelled},
+		},
+		CleanedCount: 2,
+		CleanedSize:  150,
+	}
+
+	record := NewCleanRecord(result, models.DeleteModeTrash, time.Now(), context.Canceled)
+
+	if !record.Cancelled || record.Error != "" {
+		t.Errorf("Cancelled = %v, Error = %q; want true, empty", record.Cancelled, record.Error)
+	}
+	if record.TotalCount != 2 || record.TotalSize != 150 {
+		t.Errorf("TotalCount = %d, TotalSize = %d; want 2, 150", record.TotalCount, record.TotalSize)
+	}
+	// 失败的项目不计数，但已经释放的大小与 TotalSize 一样计入
+	want := []models.RuleStat{{Rule: "Node.js", Count: 2, Size: 120}, {Rule: "Maven", Count: 0, Size: 30}}
+	if fmt.Sprint(record.RuleStats) != fmt.Sprint(want) {
+		t.Errorf("RuleStats = %+v, want %+v", record.RuleStats, want)
+	}
+	if len(record.FailedItems) != 1 || record.FailedItems[0] != "/p/b/target" {
+		t.Errorf("FailedItems = %v, want [/p/b/target]", record.FailedItems)
+	}
+}
+
+func TestConcurrentSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	// 每次保存都打开新的 Store，与 Save 的用法相同
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := New(path).Add(models.HistoryRecord{Kind: models.HistoryKindScan, StartTime: time.Now()}); err != nil {
+				t.Errorf("Add() error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	records, err := New(path).List(models.HistoryFilter{})
+	if err != nil || len(records) != 20 {
+		t.Fatalf("List() returned %d records, %v; want 20", len(records), err)
+	}
+	if matches, _ := filepath.Glob(path + ".*.tmp"); len(matches) != 0 {
+		t.Errorf("temporary files were left behind: %v", matches)
+	}
+}
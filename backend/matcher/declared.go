@@ -0,0 +1,100 @@
+package matcher
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// declaredOutputs 读取构建文件中声明的输出目录，以项目标识文件名为键
+// 函数参数为构建文件所在目录，返回声明的输出目录的绝对路径，无法识别的写法直接忽略
+var declaredOutputs = map[string]func(projectDir string) []string{
+	"pom.xml":          mavenOutputs,
+	"build.gradle":     gradleOutputs("build.gradle"),
+	"build.gradle.kts": gradleOutputs("build.gradle.kts"),
+	"Cargo.toml":       cargoOutputs,
+}
+
+// mavenOutputs 读取 pom.xml 中的 <build><directory>
+func mavenOutputs(projectDir string) []string {
+	data, err := os.ReadFile(filepath.Join(projectDir, "pom.xml"))
+	if err != nil {
+		return nil
+	}
+
+	var pom struct {
+		Build struct {
+			Directory string `xml:"directory"`
+		} `xml:"build"`
+	}
+	if err := xml.Unmarshal(data, &pom); err != nil {
+		return nil
+	}
+
+	dir := strings.TrimSpace(pom.Build.Directory)
+	for _, prefix := range []string{"${project.basedir}", "${basedir}"} {
+		if strings.HasPrefix(dir, prefix) {
+			dir = strings.TrimPrefix(strings.TrimPrefix(dir, prefix), "/")
+			break
+		}
+	}
+	return resolveOutput(projectDir, dir)
+}
+
+// gradleBuildDir 匹配 Gradle 构建文件中常见的输出目录写法：
+//
+//	buildDir = "out"
+//	buildDir = file("out")
+//	layout.buildDirectory = file("out")
+//	layout.buildDirectory.set(file("out"))
+//	layout.buildDirectory.set(layout.projectDirectory.dir("out"))
+var gradleBuildDir = regexp.MustCompile(
+	`(?m)^\s*(?:project\.)?(?:buildDir|layout\.buildDirectory)\s*(?:=|\.set\()\s*(?:file\(|layout\.projectDirectory\.dir\()?\s*["']([^"'$]+)["']`)
+
+// gradleOutputs 读取 Gradle 构建文件中设置的 buildDir
+func gradleOutputs(name string) func(projectDir string) []string {
+	return func(projectDir string) []string {
+		data, err := os.ReadFile(filepath.Join(projectDir, name))
+		if err != nil {
+			return nil
+		}
+
+		var outputs []string
+		for _, match := range gradleBuildDir.FindAllSubmatch(data, -1) {
+			outputs = append(outputs, resolveOutput(projectDir, string(match[1]))...)
+		}
+		return outputs
+	}
+}
+
+// cargoTargetDir 匹配 .cargo/config.toml 中的 target-dir = "..."
+var cargoTargetDir = regexp.MustCompile(`(?m)^\s*target-dir\s*=\s*["']([^"']+)["']`)
+
+// cargoOutputs 读取项目目录下 .cargo/config.toml（或旧的 .cargo/config）中的 build.target-dir
+// 相对路径相对于 .cargo 所在的目录
+func cargoOutputs(projectDir string) []string {
+	for _, name := range []string{"config.toml", "config"} {
+		data, err := os.ReadFile(filepath.Join(projectDir, ".cargo", name))
+		if err != nil {
+			continue
+		}
+		if match := cargoTargetDir.FindSubmatch(data); match != nil {
+			return resolveOutput(projectDir, string(match[1]))
+		}
+	}
+	return nil
+}
+
+// resolveOutput 将声明的输出目录转换为绝对路径，包含其他变量或为空时忽略
+func resolveOutput(projectDir, dir string) []string {
+	if dir == "" || strings.Contains(dir, "${") {
+		return nil
+	}
+	dir = filepath.FromSlash(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(projectDir, dir)
+	}
+	return []string{filepath.Clean(dir)}
+}
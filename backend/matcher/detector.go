@@ -0,0 +1,59 @@
+package matcher
+
+import (
+	"fast-clean-x/backend/models"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// cacheDirTagFile 缓存目录标记文件名
+const cacheDirTagFile = "CACHEDIR.TAG"
+
+// cacheDirTagSignature CACHEDIR.TAG 文件必须以这个签名开头，见 https://bford.info/cachedir/
+const cacheDirTagSignature = "Signature: 8a477f597d28d172789f06886806bc55"
+
+// anyDir 检测器规则匹配的模式，检测器按目录内容而不是目录名判断
+var anyDir = MustCompile("*")
+
+// detector 内置检测器
+type detector struct {
+	file   string // 检测器读取的文件，目录中没有该文件时一定不匹配
+	detect func(dirPath string) bool
+}
+
+// detectors 内置检测器，以 models.Detector* 常量为键
+var detectors = map[string]detector{
+	models.DetectorCacheDirTag: {file: cacheDirTagFile, detect: HasCacheDirTag},
+}
+
+// IsDetector 判断是否为已知的内置检测器
+func IsDetector(name string) bool {
+	_, ok := detectors[name]
+	return ok
+}
+
+// IsDetectorFile 判断文件名是否为某个检测器读取的文件，遍历目录时只需要记录这些文件
+func IsDetectorFile(name string) bool {
+	for _, d := range detectors {
+		if d.file == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCacheDirTag 判断目录中是否有带标准签名的 CACHEDIR.TAG 文件
+func HasCacheDirTag(dirPath string) bool {
+	f, err := os.Open(filepath.Join(dirPath, cacheDirTagFile))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	buf := make([]byte, len(cacheDirTagSignature))
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return false
+	}
+	return string(buf) == cacheDirTagSignature
+}
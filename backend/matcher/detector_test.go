@@ -0,0 +1,28 @@
+package matcher
+
+import (
+	"fast-clean-x/backend/models"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMatchListedTarget(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, cacheDirTagFile), cacheDirTagSignature+"\n")
+	rule := CompileRule(models.ScanRule{Name: "CACHEDIR.TAG", Detector: models.DetectorCacheDirTag})
+
+	listed := func(names ...string) func(string) bool {
+		return func(name string) bool { return slices.Contains(names, name) }
+	}
+	if _, ok := rule.MatchListedTarget(dir, listed(cacheDirTagFile)); !ok {
+		t.Error("MatchListedTarget() with the tag listed = false")
+	}
+	// 目录项中没有标记文件时不打开文件
+	if _, ok := rule.MatchListedTarget(dir, listed("data")); ok {
+		t.Error("MatchListedTarget() without the tag listed = true")
+	}
+	if _, ok := rule.MatchTarget(dir); !ok {
+		t.Error("MatchTarget() = false")
+	}
+}
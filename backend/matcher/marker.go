@@ -0,0 +1,85 @@
+package matcher
+
+import (
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"path/filepath"
+)
+
+// maxMarkerDepth 向上查找项目标识的最大层数，与 utils.FindNearestMarker 一致
+const maxMarkerDepth = 10
+
+// MatchMarkers 检查目录相对项目标识的位置是否符合规则的 MarkerScope，返回项目标识所在的目录
+// pattern 为匹配该目录的目标目录模式。规则不要求项目标识时总是返回 true
+func (r *Rule) MatchMarkers(dirPath string, pattern *Pattern) (string, bool) {
+	if !r.RequireMarkers || len(r.ProjectMarkers) == 0 {
+		return "", true
+	}
+
+	anchor := pattern.Anchor(dirPath)
+	switch r.MarkerScope {
+	case models.MarkerScopeSibling:
+		return r.findMarker(anchor, 0)
+	case models.MarkerScopeWithin:
+		return r.findMarker(anchor, r.MarkerDepth)
+	case models.MarkerScopeDeclared:
+		if dir, ok := r.findMarker(anchor, 0); ok {
+			return dir, true
+		}
+		return r.findDeclared(dirPath, anchor)
+	default:
+		dir := utils.FindNearestMarker(dirPath, r.ProjectMarkers)
+		return dir, dir != ""
+	}
+}
+
+// findMarker 在 dir 及其上方 depth 层以内查找项目标识
+func (r *Rule) findMarker(dir string, depth int) (string, bool) {
+	for i := 0; i <= depth; i++ {
+		if r.hasMarker(dir) {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", false
+}
+
+// findDeclared 向上查找声明了该输出目录的构建文件
+func (r *Rule) findDeclared(dirPath, anchor string) (string, bool) {
+	dirPath = filepath.Clean(dirPath)
+	dir := anchor
+	for i := 0; i < maxMarkerDepth; i++ {
+		for _, marker := range r.ProjectMarkers {
+			read, ok := declaredOutputs[marker]
+			if !ok || !utils.PathExists(filepath.Join(dir, marker)) {
+				continue
+			}
+			for _, output := range read(dir) {
+				if output == dirPath {
+					return dir, true
+				}
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", false
+}
+
+// hasMarker 判断目录中是否有任一项目标识
+func (r *Rule) hasMarker(dir string) bool {
+	for _, marker := range r.ProjectMarkers {
+		if utils.HasMarker(dir, marker) {
+			return true
+		}
+	}
+	return false
+}
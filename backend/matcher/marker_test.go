@@ -0,0 +1,78 @@
+package matcher
+
+import (
+	"fast-clean-x/backend/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile 创建文件及其父目录
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMatchMarkers(t *testing.T) {
+	root := t.TempDir()
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{root}, elem...)...)
+	}
+
+	writeFile(t, join("gradle", "build.gradle"), "")
+	writeFile(t, join("gradle", "src", "main", "build", "Main.java"), "")
+	writeFile(t, join("custom", "build.gradle.kts"), `layout.buildDirectory.set(file("out/gradle/build"))`)
+	writeFile(t, join("maven", "pom.xml"), `<project><build><directory>${project.basedir}/out/target</directory></build></project>`)
+	writeFile(t, join("cargo", "Cargo.toml"), "")
+	writeFile(t, join("cargo", ".cargo", "config.toml"), "[build]\ntarget-dir = \"out/target\"\n")
+	writeFile(t, join("dotnet", "App.csproj"), "")
+
+	rule := func(scope string, depth int, markers ...string) *Rule {
+		return CompileRule(models.ScanRule{
+			Name:           "Test",
+			TargetDirs:     []string{"build", "target", "bin/Debug"},
+			ProjectMarkers: markers,
+			RequireMarkers: true,
+			MarkerScope:    scope,
+			MarkerDepth:    depth,
+		})
+	}
+
+	tests := []struct {
+		name string
+		rule *Rule
+		path string
+		want bool
+	}{
+		{"ancestor 允许深层目录", rule(models.MarkerScopeAncestor, 0, "build.gradle"), join("gradle", "src", "main", "build"), true},
+		{"sibling 拒绝源码中的同名目录", rule(models.MarkerScopeSibling, 0, "build.gradle"), join("gradle", "src", "main", "build"), false},
+		{"sibling 允许项目根目录下的目录", rule(models.MarkerScopeSibling, 0, "build.gradle"), join("gradle", "build"), true},
+		{"within 层数不足", rule(models.MarkerScopeWithin, 1, "build.gradle"), join("gradle", "src", "main", "build"), false},
+		{"within 层数足够", rule(models.MarkerScopeWithin, 2, "build.gradle"), join("gradle", "src", "main", "build"), true},
+		{"多段模式相对第一段判断", rule(models.MarkerScopeSibling, 0, "App.csproj"), join("dotnet", "bin", "Debug"), true},
+		{"通配符项目标识", rule(models.MarkerScopeSibling, 0, "*.csproj"), join("dotnet", "bin", "Debug"), true},
+		{"通配符项目标识不匹配", rule(models.MarkerScopeSibling, 0, "*.fsproj"), join("dotnet", "bin", "Debug"), false},
+		{"declared 拒绝源码中的同名目录", rule(models.MarkerScopeDeclared, 0, "build.gradle"), join("gradle", "src", "main", "build"), false},
+		{"declared Gradle buildDirectory", rule(models.MarkerScopeDeclared, 0, "build.gradle.kts"), join("custom", "out", "gradle", "build"), true},
+		{"declared Maven build.directory", rule(models.MarkerScopeDeclared, 0, "pom.xml"), join("maven", "out", "target"), true},
+		{"declared 未声明的位置", rule(models.MarkerScopeDeclared, 0, "pom.xml"), join("maven", "out", "other", "target"), false},
+		{"declared Cargo target-dir", rule(models.MarkerScopeDeclared, 0, "Cargo.toml"), join("cargo", "out", "target"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, ok := tt.rule.MatchTarget(tt.path)
+			if !ok {
+				t.Fatalf("MatchTarget(%s) = false", tt.path)
+			}
+			if _, got := tt.rule.MatchMarkers(tt.path, pattern); got != tt.want {
+				t.Errorf("MatchMarkers(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
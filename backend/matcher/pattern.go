@@ -0,0 +1,192 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// doubleStar 匹配任意多层目录的路径段
+const doubleStar = "**"
+
+// Pattern 编译后的目标目录模式
+//
+// 模式使用 / 分隔路径段，与操作系统无关，每个路径段支持 path.Match 的通配符（*、?、[...]），
+// ** 作为单独的路径段时匹配零或多层目录。模式匹配目录路径的末尾，例如：
+//   - node_modules：目录名等于 node_modules
+//   - cmake-build-*、*.egg-info：目录名匹配通配符
+//   - bin/Debug：名为 Debug 且上级目录名为 bin 的目录
+//   - build/**/intermediates：位于某个 build 目录之下任意层的 intermediates 目录
+type Pattern struct {
+	raw      string
+	segments []string
+	literal  bool // 只有一个不含通配符的路径段，直接比较目录名
+	anyDepth bool // 包含 ** 路径段
+}
+
+// Compile 编译目标目录模式
+func Compile(pattern string) (*Pattern, error) {
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+	if strings.HasPrefix(pattern, "/") || filepath.IsAbs(pattern) {
+		return nil, fmt.Errorf("pattern must be relative: %s", pattern)
+	}
+	if strings.Contains(pattern, `\`) {
+		return nil, fmt.Errorf("pattern must use / as separator: %s", pattern)
+	}
+
+	segments := strings.Split(strings.TrimSuffix(pattern, "/"), "/")
+	p := &Pattern{raw: pattern, segments: segments}
+	for _, segment := range segments {
+		switch segment {
+		case "", ".", "..":
+			return nil, fmt.Errorf("invalid path segment %q in pattern: %s", segment, pattern)
+		case doubleStar:
+			p.anyDepth = true
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+	}
+	if segments[len(segments)-1] == doubleStar {
+		return nil, fmt.Errorf("pattern must end with a directory name: %s", pattern)
+	}
+
+	p.literal = len(segments) == 1 && !hasMeta(segments[0])
+	return p, nil
+}
+
+// MustCompile 编译目标目录模式，模式无效时 panic，用于内置规则
+func MustCompile(pattern string) *Pattern {
+	p, err := Compile(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String 返回原始模式
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Match 判断目录路径的末尾是否匹配模式
+func (p *Pattern) Match(dirPath string) bool {
+	if p.literal {
+		return filepath.Base(dirPath) == p.raw
+	}
+	// 先检查目录名，大部分目录在这里就被排除
+	if !matchSegment(p.segments[len(p.segments)-1], filepath.Base(dirPath)) {
+		return false
+	}
+	if len(p.segments) == 1 {
+		return true
+	}
+
+	if !p.anyDepth {
+		// 只取路径末尾需要的几段，避免拆分整个路径
+		names := lastSegments(dirPath, len(p.segments))
+		if len(names) < len(p.segments) {
+			return false
+		}
+		for i, segment := range p.segments {
+			if !matchSegment(segment, names[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	names := strings.Split(filepath.ToSlash(filepath.Clean(dirPath)), "/")
+	return matchSuffix(p.segments, names)
+}
+
+// Anchor 返回匹配的目录路径中模式第一段所在的目录
+// 例如模式 bin/Debug 匹配 /app/bin/Debug 时返回 /app，项目标识的位置相对这个目录判断。
+// 路径不匹配模式时返回上级目录
+func (p *Pattern) Anchor(dirPath string) string {
+	dirPath = filepath.Clean(dirPath)
+	levels := len(p.segments)
+	if p.anyDepth {
+		names := strings.Split(filepath.ToSlash(dirPath), "/")
+		levels = 1
+		for start := len(names) - 1; start >= 0; start-- {
+			if matchAll(p.segments, names[start:]) {
+				levels = len(names) - start
+				break
+			}
+		}
+	}
+
+	anchor := dirPath
+	for i := 0; i < levels; i++ {
+		anchor = filepath.Dir(anchor)
+	}
+	return anchor
+}
+
+// MatchName 判断目录名是否匹配单段模式，多段模式总是返回 false
+func (p *Pattern) MatchName(name string) bool {
+	return len(p.segments) == 1 && matchSegment(p.segments[0], name)
+}
+
+// matchSuffix 判断 names 的某个后缀是否匹配 segments（模式开头相当于隐含了 **）
+func matchSuffix(segments, names []string) bool {
+	for start := len(names) - 1; start >= 0; start-- {
+		if matchAll(segments, names[start:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAll 判断 names 是否完整匹配 segments
+func matchAll(segments, names []string) bool {
+	for len(segments) > 0 {
+		if segments[0] == doubleStar {
+			for skip := 0; skip <= len(names); skip++ {
+				if matchAll(segments[1:], names[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(names) == 0 || !matchSegment(segments[0], names[0]) {
+			return false
+		}
+		segments, names = segments[1:], names[1:]
+	}
+	return len(names) == 0
+}
+
+// matchSegment 匹配单个路径段
+func matchSegment(segment, name string) bool {
+	matched, err := path.Match(segment, name)
+	return err == nil && matched
+}
+
+// lastSegments 返回路径末尾的 n 段，路径不足 n 段时返回全部
+func lastSegments(dirPath string, n int) []string {
+	names := make([]string, n)
+	current := filepath.Clean(dirPath)
+	i := n - 1
+	for ; i >= 0; i-- {
+		name := filepath.Base(current)
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		names[i] = name
+		current = parent
+	}
+	return names[i+1:]
+}
+
+// hasMeta 判断路径段是否包含通配符
+func hasMeta(segment string) bool {
+	return strings.ContainsAny(segment, `*?[`)
+}
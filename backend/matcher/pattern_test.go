@@ -0,0 +1,59 @@
+package matcher
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"node_modules", "/work/app/node_modules", true},
+		{"node_modules", "/work/app/node_modules_old", false},
+		{"cmake-build-*", "/work/app/cmake-build-debug", true},
+		{"cmake-build-*", "/work/app/build", false},
+		{"*.egg-info", "/work/lib/mylib.egg-info", true},
+		{"bin/Debug", "/work/App/bin/Debug", true},
+		{"bin/Debug", "/work/App/obj/Debug", false},
+		{"bin/Debug", "/Debug", false},
+		{".terraform/providers", "/infra/.terraform/providers", true},
+		{"bin/*", "/work/App/bin/Release", true},
+		{"build/**/intermediates", "/app/build/intermediates", true},
+		{"build/**/intermediates", "/app/build/a/b/intermediates", true},
+		{"build/**/intermediates", "/app/src/intermediates", false},
+		{"**/out", "/app/out", true},
+	}
+
+	for _, tt := range tests {
+		p, err := Compile(tt.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q) error: %v", tt.pattern, err)
+		}
+		if got := p.Match(filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("Compile(%q).Match(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	for _, pattern := range []string{"", "/abs", "a/../b", "./a", "a//b", `bin\Debug`, "a[", "build/**"} {
+		if _, err := Compile(pattern); err == nil {
+			t.Errorf("Compile(%q) = nil error, want error", pattern)
+		}
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	path := filepath.FromSlash("/home/user/workspace/project/module/build/intermediates")
+	for _, pattern := range []string{"node_modules", "cmake-build-*", "bin/Debug", "build/**/intermediates"} {
+		p := MustCompile(pattern)
+		b.Run(pattern, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p.Match(path)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package matcher
+
+import (
+	"fast-clean-x/backend/models"
+)
+
+// Rule 编译后的扫描规则，扫描和删除前校验共用同一套匹配逻辑
+type Rule struct {
+	models.ScanRule
+	targets []*Pattern
+}
+
+// CompileRule 编译扫描规则的目标目录模式
+// 无效的模式被忽略，规则在保存到配置时已经校验过
+func CompileRule(rule models.ScanRule) *Rule {
+	r := &Rule{ScanRule: rule, targets: make([]*Pattern, 0, len(rule.TargetDirs))}
+	for _, target := range rule.TargetDirs {
+		if p, err := Compile(target); err == nil {
+			r.targets = append(r.targets, p)
+		}
+	}
+	return r
+}
+
+// CompileRules 编译所有扫描规则
+func CompileRules(rules []models.ScanRule) []*Rule {
+	compiled := make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		compiled = append(compiled, CompileRule(rule))
+	}
+	return compiled
+}
+
+// MatchTarget 判断目录是否匹配规则的某个目标目录模式，返回匹配的模式
+// 设置了检测器的规则由检测器判断，返回匹配任意目录名的模式
+func (r *Rule) MatchTarget(dirPath string) (*Pattern, bool) {
+	return r.MatchListedTarget(dirPath, nil)
+}
+
+// MatchListedTarget 与 MatchTarget 相同，hasFile 根据调用方已经读取的目录项判断目录中是否有某个文件，
+// 检测器只在目录中有它读取的文件时才打开文件，避免对每个遍历到的目录都访问文件系统
+// hasFile 为 nil 表示没有读取目录项，检测器直接检查
+func (r *Rule) MatchListedTarget(dirPath string, hasFile func(name string) bool) (*Pattern, bool) {
+	if r.Detector != "" {
+		d, ok := detectors[r.Detector]
+		if !ok || (hasFile != nil && !hasFile(d.file)) {
+			return anyDir, false
+		}
+		return anyDir, d.detect(dirPath)
+	}
+	for _, p := range r.targets {
+		if p.Match(dirPath) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// IsOwnTarget 判断目录名是否是规则自己的目标目录，用于豁免全局排除
+func (r *Rule) IsOwnTarget(name string) bool {
+	for _, p := range r.targets {
+		if p.MatchName(name) {
+			return true
+		}
+	}
+	return false
+}
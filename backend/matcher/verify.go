@@ -0,0 +1,50 @@
+package matcher
+
+import (
+	"fast-clean-x/backend/utils"
+	"path/filepath"
+)
+
+// Verification 目标目录内容校验的结果
+type Verification int
+
+const (
+	VerifyNone     Verification = iota // 没有适用于该目标目录的内容校验
+	VerifyPassed                       // 内容校验通过，检测器规则总是通过
+	VerifyFailed                       // 内容校验没有通过，但都不是必需的
+	VerifyRejected                     // 必需的内容校验没有通过，目录不作为匹配
+)
+
+// Verify 按规则中适用于匹配模式的内容校验检查目录，扫描和删除前校验共用
+func (r *Rule) Verify(dirPath string, pattern *Pattern) Verification {
+	// 检测器已经检查过目录内容
+	if r.Detector != "" {
+		return VerifyPassed
+	}
+
+	result := VerifyNone
+	for _, verifier := range r.Verifiers {
+		if verifier.Target != pattern.String() {
+			continue
+		}
+		if containsAny(dirPath, verifier.Contains) {
+			return VerifyPassed
+		}
+		if verifier.Required {
+			result = VerifyRejected
+		} else if result == VerifyNone {
+			result = VerifyFailed
+		}
+	}
+	return result
+}
+
+// containsAny 判断目录中是否存在任意一项
+func containsAny(dir string, entries []string) bool {
+	for _, entry := range entries {
+		if utils.PathExists(filepath.Join(dir, filepath.FromSlash(entry))) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,53 +4,194 @@ import "time"
 
 // ScanRule 扫描规则
 type ScanRule struct {
-	Name              string   `json:"name"`              // 规则名称，如 "Maven"
-	Description       string   `json:"description"`       // 规则描述
-	TargetDirs        []string `json:"targetDirs"`        // 要扫描的目录名，如 ["target"]
-	Enabled           bool     `json:"enabled"`           // 是否启用
-	Priority          int      `json:"priority"`          // 优先级（数字越大优先级越高）
-	ProjectMarkers    []string `json:"projectMarkers"`    // 项目标识文件，用于确认项目类型
-	RequireMarkers    bool     `json:"requireMarkers"`    // 是否必须验证项目标识（减少误判）
-	ExcludeFromGlobal bool     `json:"excludeFromGlobal"` // 是否从全局排除中豁免（只豁免自己的目标目录）
+	Name              string           `json:"name"`              // 规则名称，如 "Maven"
+	Description       string           `json:"description"`       // 规则描述
+	TargetDirs        []string         `json:"targetDirs"`        // 要扫描的目录名，如 ["target"]
+	Enabled           bool             `json:"enabled"`           // 是否启用
+	Priority          int              `json:"priority"`          // 优先级（数字越大优先级越高）
+	ProjectMarkers    []string         `json:"projectMarkers"`    // 项目标识文件，用于确认项目类型
+	RequireMarkers    bool             `json:"requireMarkers"`    // 是否必须验证项目标识（减少误判）
+	ExcludeFromGlobal bool             `json:"excludeFromGlobal"` // 是否从全局排除中豁免（只豁免自己的目标目录）
+	MarkerScope       string           `json:"markerScope"`       // 目标目录相对项目标识的位置，见 MarkerScope* 常量，为空时同 ancestor
+	MarkerDepth       int              `json:"markerDepth"`       // MarkerScope 为 within 时，项目标识最多位于目标目录所在目录上方几层
+	Verifiers         []TargetVerifier `json:"verifiers"`         // 目标目录的内容校验，可选
+	Detector          string           `json:"detector"`          // 内置检测器，见 Detector* 常量，设置后由检测器判断目录而不使用 TargetDirs
+	Custom            bool             `json:"custom"`            // 是否为用户自定义规则，内置规则升级时不会覆盖自定义规则
 }
 
+// TargetVerifier 目标目录的内容校验，用于确认同名目录确实是构建输出
+type TargetVerifier struct {
+	Target   string   `json:"target"`   // 适用的目标目录模式，与 TargetDirs 中的一项相同
+	Contains []string `json:"contains"` // 目标目录中必须存在其中任意一项（相对路径）
+	Required bool     `json:"required"` // 为 true 时校验不通过则不匹配，否则只降低置信度并默认不选中
+}
+
+// 内置检测器
+const (
+	DetectorCacheDirTag = "cachedir-tag" // 包含带有标准签名的 CACHEDIR.TAG 文件的目录
+)
+
+// 扫描项的置信度，低于 ConfidenceName 的扫描项默认不选中
+const (
+	ConfidenceVerified   = 100 // 目标目录的内容通过校验
+	ConfidenceMarker     = 80  // 在规则要求的位置找到了项目标识
+	ConfidenceName       = 60  // 只有目录名匹配
+	ConfidenceUnverified = 30  // 配置了内容校验但没有通过
+)
+
+// 项目标识的查找范围，RequireMarkers 为 true 时生效
+const (
+	MarkerScopeAncestor = "ancestor" // 向上最多 10 层内任意位置有项目标识
+	MarkerScopeSibling  = "sibling"  // 项目标识与目标目录位于同一目录
+	MarkerScopeWithin   = "within"   // 项目标识位于目标目录所在目录或其上方 MarkerDepth 层以内
+	MarkerScopeDeclared = "declared" // 同 sibling，或者目标目录是构建文件中声明的输出目录（如 pom.xml 的 build.directory）
+)
+
+// 删除方式
+const (
+	DeleteModePermanent  = "permanent"  // 永久删除
+	DeleteModeTrash      = "trash"      // 移到系统回收站
+	DeleteModeQuarantine = "quarantine" // 移到隔离区，保留期内可恢复
+)
+
+// 大小统计方式
+const (
+	SizeModeApparent = "apparent" // 文件内容大小之和，与 ls 一致
+	SizeModeDisk     = "disk"     // 实际占用的磁盘空间，硬链接只计算一次，与 du/df 一致
+)
+
 // Config 应用配置
 type Config struct {
 	ScanPaths          []string   `json:"scanPaths"`          // 扫描路径列表
 	IgnorePatterns     []string   `json:"ignorePatterns"`     // 忽略的项目路径模式
 	GlobalPathExcludes []string   `json:"globalPathExcludes"` // 全局路径排除（应用于所有规则）
+	ProtectedPaths     []string   `json:"protectedPaths"`     // 受保护的路径，本身及其中的内容永远不会被清理
 	ScanRules          []ScanRule `json:"scanRules"`          // 扫描规则
+	DeleteMode         string     `json:"deleteMode"`         // 删除方式，见 DeleteMode* 常量
+	SizeMode           string     `json:"sizeMode"`           // 大小统计方式，见 SizeMode* 常量
+	CleanConcurrency   int        `json:"cleanConcurrency"`   // 清理并发数，0 表示根据磁盘类型自动选择
+	QuarantineDays     int        `json:"quarantineDays"`     // 隔离区保留天数，过期自动永久删除
 	LastScanTime       time.Time  `json:"lastScanTime"`       // 上次扫描时间
 }
 
 // ScanItem 扫描到的单个项目
 type ScanItem struct {
-	Path         string    `json:"path"`         // 完整路径
-	ProjectPath  string    `json:"projectPath"`  // 项目根路径
-	ProjectName  string    `json:"projectName"`  // 项目名称
-	Type         string    `json:"type"`         // 类型，如 "maven", "gradle", "node"
-	Size         int64     `json:"size"`         // 大小（字节）
-	SizeReadable string    `json:"sizeReadable"` // 可读的大小，如 "1.2 GB"
-	FileCount    int       `json:"fileCount"`    // 文件数量
-	LastModified time.Time `json:"lastModified"` // 最后修改时间
-	Selected     bool      `json:"selected"`     // 是否选中（用于删除）
+	Path         string         `json:"path"`         // 完整路径
+	ProjectPath  string         `json:"projectPath"`  // 项目根路径
+	ProjectName  string         `json:"projectName"`  // 项目名称
+	Type         string         `json:"type"`         // 类型，如 "maven", "gradle", "node"
+	Size         int64          `json:"size"`         // 大小（字节），按配置的统计方式
+	ApparentSize int64          `json:"apparentSize"` // 文件内容大小之和（字节）
+	DiskSize     int64          `json:"diskSize"`     // 实际占用的磁盘空间（字节）
+	SizeReadable string         `json:"sizeReadable"` // 可读的大小，如 "1.2 GB"
+	FileCount    int            `json:"fileCount"`    // 文件数量
+	LastModified time.Time      `json:"lastModified"` // 最后修改时间
+	Selected     bool           `json:"selected"`     // 是否选中（用于删除）
+	Confidence   int            `json:"confidence"`   // 置信度（0-100），表示目录是可以删除的构建输出的把握，见 Confidence* 常量
+	SizePending  bool           `json:"sizePending"`  // 大小是否还在计算中
+	Decisions    []RuleDecision `json:"decisions"`    // 目标目录模式匹配该目录的各条规则的判断，说明为什么选择了 Type
+}
+
+// RuleDecision 一条扫描规则对目录的判断
+type RuleDecision struct {
+	Rule       string `json:"rule"`       // 规则名称
+	Matched    bool   `json:"matched"`    // 是否通过了规则的全部检查
+	Selected   bool   `json:"selected"`   // 是否被选为扫描项的类型
+	Confidence int    `json:"confidence"` // 通过检查时的置信度
+	Reason     string `json:"reason"`     // 未通过检查、被选中或落选的原因
+}
+
+// MatchExplanation 单个目录完整的扫描判断过程
+type MatchExplanation struct {
+	Path       string         `json:"path"`       // 目录路径
+	Matched    bool           `json:"matched"`    // 扫描时是否会作为扫描项
+	Type       string         `json:"type"`       // 选择的规则，目录本身被跳过时为跳过前会选择的规则
+	Confidence int            `json:"confidence"` // 选择的规则的置信度
+	Skipped    string         `json:"skipped"`    // 扫描时不会访问或检查该目录的原因，为空表示会检查
+	Decisions  []RuleDecision `json:"decisions"`  // 目标目录模式匹配该目录的各条规则（包括已禁用的规则）的判断
+}
+
+// 扫描项事件类型
+const (
+	ScanItemFound   = "found"   // 找到匹配的目录，大小还在计算中
+	ScanItemUpdated = "updated" // 大小计算完成
+	ScanItemRemoved = "removed" // 目录已不存在，从结果中移除
+)
+
+// ScanItemEvent 扫描过程中的单个扫描项事件，用于实时展示扫描结果
+type ScanItemEvent struct {
+	Kind string   `json:"kind"` // 事件类型，见 ScanItem* 常量
+	Item ScanItem `json:"item"` // 扫描项，以 Path 区分
 }
 
 // ScanResult 扫描结果
 type ScanResult struct {
-	Items      []ScanItem `json:"items"`      // 扫描到的项目列表
-	TotalSize  int64      `json:"totalSize"`  // 总大小
-	TotalCount int        `json:"totalCount"` // 总数量
-	ScanTime   time.Time  `json:"scanTime"`   // 扫描时间
+	Items       []ScanItem `json:"items"`       // 扫描到的项目列表
+	TotalSize   int64      `json:"totalSize"`   // 总大小
+	TotalCount  int        `json:"totalCount"`  // 总数量
+	ScanTime    time.Time  `json:"scanTime"`    // 扫描时间
+	Cancelled   bool       `json:"cancelled"`   // 是否被取消，取消时只包含已找到的部分结果
+	Complete    bool       `json:"complete"`    // 是否完整扫描了所有路径
+	CachedCount int        `json:"cachedCount"` // 大小来自增量扫描缓存的项目数量
+}
+
+// QuarantineEntry 隔离区中的一条记录
+type QuarantineEntry struct {
+	ID            string    `json:"id"`            // 记录 ID
+	OriginalPath  string    `json:"originalPath"`  // 原始路径
+	ProjectName   string    `json:"projectName"`   // 项目名称
+	Type          string    `json:"type"`          // 匹配的规则，如 "Maven"
+	Size          int64     `json:"size"`          // 大小（字节）
+	SizeReadable  string    `json:"sizeReadable"`  // 可读的大小
+	QuarantinedAt time.Time `json:"quarantinedAt"` // 隔离时间
+	ExpiresAt     time.Time `json:"expiresAt"`     // 过期时间，过期后自动永久删除
+}
+
+// 历史记录类型
+const (
+	HistoryKindScan  = "scan"  // 扫描
+	HistoryKindClean = "clean" // 清理
+)
+
+// RuleStat 按规则统计的数量和大小
+type RuleStat struct {
+	Rule  string `json:"rule"`  // 规则名称
+	Count int    `json:"count"` // 目录数量
+	Size  int64  `json:"size"`  // 大小（字节）
+}
+
+// HistoryRecord 一次扫描或清理的历史记录
+type HistoryRecord struct {
+	ID          string     `json:"id"`                    // 记录 ID
+	Kind        string     `json:"kind"`                  // 类型，见 HistoryKind* 常量
+	StartTime   time.Time  `json:"startTime"`             // 开始时间
+	Duration    int64      `json:"duration"`              // 耗时（毫秒）
+	ScanPaths   []string   `json:"scanPaths,omitempty"`   // 扫描路径（扫描记录）
+	DeleteMode  string     `json:"deleteMode,omitempty"`  // 删除方式（清理记录）
+	TotalCount  int        `json:"totalCount"`            // 扫描到的数量 / 成功清理的数量
+	TotalSize   int64      `json:"totalSize"`             // 扫描到的大小 / 释放的大小
+	MovedSize   int64      `json:"movedSize,omitempty"`   // 移到回收站或隔离区的大小（清理记录）
+	FailedItems []string   `json:"failedItems,omitempty"` // 清理失败的项目
+	RuleStats   []RuleStat `json:"ruleStats"`             // 按规则统计
+	Cancelled   bool       `json:"cancelled"`             // 是否被取消
+	Error       string     `json:"error,omitempty"`       // 错误信息
+}
+
+// HistoryFilter 历史记录查询条件，零值表示不限制
+type HistoryFilter struct {
+	Kind  string    `json:"kind"`  // 记录类型
+	Since time.Time `json:"since"` // 开始时间不早于
+	Until time.Time `json:"until"` // 开始时间不晚于
 }
 
 // ScanProgress 扫描进度
 type ScanProgress struct {
 	CurrentPath  string `json:"currentPath"`  // 当前扫描路径
-	ScannedCount int    `json:"scannedCount"` // 已扫描数量
+	ScannedCount int    `json:"scannedCount"` // 已扫描的目录数量
+	MatchedCount int    `json:"matchedCount"` // 已找到的可清理目录数量
 	TotalSize    int64  `json:"totalSize"`    // 已发现的总大小
 	IsScanning   bool   `json:"isScanning"`   // 是否正在扫描
-	Progress     int    `json:"progress"`     // 进度百分比 (0-100)
+	Progress     int    `json:"progress"`     // 估算的进度百分比 (0-100)
 }
 
 // CleanProgress 清理进度
@@ -58,10 +199,165 @@ type CleanProgress struct {
 	CurrentPath  string   `json:"currentPath"`  // 当前清理路径
 	CleanedCount int      `json:"cleanedCount"` // 已清理数量
 	TotalCount   int      `json:"totalCount"`   // 总数量
-	CleanedSize  int64    `json:"cleanedSize"`  // 已清理大小
+	CleanedSize  int64    `json:"cleanedSize"`  // 已释放的大小
+	MovedSize    int64    `json:"movedSize"`    // 已移到回收站或隔离区的大小，清空后才释放
 	IsCleaning   bool     `json:"isCleaning"`   // 是否正在清理
 	Progress     int      `json:"progress"`     // 进度百分比 (0-100)
 	FailedItems  []string `json:"failedItems"`  // 清理失败的项目
+	DryRun       bool     `json:"dryRun"`       // 是否为模拟清理（不删除文件）
+
+	Failures   []CleanFailure   `json:"failures"`             // 清理失败的详细信息
+	Rejections []CleanRejection `json:"rejections"`           // 删除前校验未通过的项目
+	LastResult *CleanItemResult `json:"lastResult,omitempty"` // 刚处理完的项目结果
+}
+
+// 单个项目的清理状态
+const (
+	CleanStatusSuccess   = "success"   // 清理成功
+	CleanStatusFailed    = "failed"    // 清理失败
+	CleanStatusSkipped   = "skipped"   // 跳过（未选中或已不存在）
+	CleanStatusCancelled = "cancelled" // 清理被取消，未处理
+	CleanStatusRejected  = "rejected"  // 删除前校验未通过，拒绝删除
+)
+
+// 清理失败的错误类型
+const (
+	FailureKindPermission  = "permission"  // 权限不足
+	FailureKindBusy        = "busy"        // 文件被其他进程占用
+	FailureKindReadOnly    = "readOnly"    // 只读文件系统
+	FailureKindNotFound    = "notFound"    // 路径已不存在
+	FailureKindUnsupported = "unsupported" // 删除方式在当前环境不可用
+	FailureKindUnknown     = "unknown"     // 其他错误
+)
+
+// CleanFailure 清理失败的详细信息
+type CleanFailure struct {
+	Path           string `json:"path"`           // 清理的目录
+	Kind           string `json:"kind"`           // 错误类型，见 FailureKind* 常量
+	Error          string `json:"error"`          // 系统返回的错误信息
+	FailedPath     string `json:"failedPath"`     // 出错的具体文件
+	RemovedSize    int64  `json:"removedSize"`    // 失败前已删除的大小
+	RemainingSize  int64  `json:"remainingSize"`  // 剩余未删除的大小
+	RemainingFiles int    `json:"remainingFiles"` // 剩余未删除的文件数
+	Suggestion     string `json:"suggestion"`     // 建议的解决方法
+}
+
+// CleanItemResult 单个项目的清理结果
+type CleanItemResult struct {
+	Path        string        `json:"path"`              // 完整路径
+	ProjectName string        `json:"projectName"`       // 项目名称
+	Type        string        `json:"type"`              // 匹配的规则
+	Status      string        `json:"status"`            // 清理状态，见 CleanStatus* 常量
+	Error       string        `json:"error"`             // 失败或跳过的原因
+	FreedSize   int64         `json:"freedSize"`         // 释放的大小（字节）
+	MovedSize   int64         `json:"movedSize"`         // 移到回收站或隔离区的大小（字节），清空后才释放
+	Failure     *CleanFailure `json:"failure,omitempty"` // 失败详情
+}
+
+// CleanRejection 删除前校验未通过的项目
+type CleanRejection struct {
+	Path   string `json:"path"`   // 路径
+	Reason string `json:"reason"` // 拒绝原因
+}
+
+// CleanResult 一次清理的结果，Items 与传入的扫描项一一对应
+type CleanResult struct {
+	Items          []CleanItemResult `json:"items"`          // 每个项目的清理结果
+	CleanedCount   int               `json:"cleanedCount"`   // 成功数量
+	CleanedSize    int64             `json:"cleanedSize"`    // 释放的大小
+	MovedSize      int64             `json:"movedSize"`      // 移到回收站或隔离区的大小，清空后才释放
+	FailedCount    int               `json:"failedCount"`    // 失败数量
+	SkippedCount   int               `json:"skippedCount"`   // 跳过数量
+	CancelledCount int               `json:"cancelledCount"` // 取消数量
+	RejectedCount  int               `json:"rejectedCount"`  // 校验未通过数量
+	Cancelled      bool              `json:"cancelled"`      // 是否被取消
+	Duration       int64             `json:"duration"`       // 耗时（毫秒）
+}
+
+// 清理计划中的操作
+const (
+	PlanActionDelete = "delete" // 将被删除
+	PlanActionSkip   = "skip"   // 将被跳过
+	PlanActionReject = "reject" // 删除前校验未通过，将被拒绝
+)
+
+// CleanPlanItem 清理计划中的单个项目
+type CleanPlanItem struct {
+	Path         string `json:"path"`         // 完整路径
+	ProjectName  string `json:"projectName"`  // 项目名称
+	Type         string `json:"type"`         // 匹配的规则
+	Exists       bool   `json:"exists"`       // 是否仍然存在
+	ScannedSize  int64  `json:"scannedSize"`  // 扫描时的大小
+	CurrentSize  int64  `json:"currentSize"`  // 重新计算的大小
+	SizeReadable string `json:"sizeReadable"` // 可读的当前大小
+	FileCount    int    `json:"fileCount"`    // 当前文件数量
+	Action       string `json:"action"`       // 操作，见 PlanAction* 常量
+	Reason       string `json:"reason"`       // 跳过或拒绝的原因
+}
+
+// CleanPlan 模拟清理生成的清理计划
+type CleanPlan struct {
+	Items         []CleanPlanItem `json:"items"`         // 选中的项目
+	TotalCount    int             `json:"totalCount"`    // 将被删除的数量
+	TotalSize     int64           `json:"totalSize"`     // 将释放的大小
+	SkippedCount  int             `json:"skippedCount"`  // 将被跳过的数量
+	RejectedCount int             `json:"rejectedCount"` // 校验未通过的数量
+	CreatedAt     time.Time       `json:"createdAt"`     // 生成时间
+}
+
+// 全局缓存的清理方式
+const (
+	GlobalCachePrune = "prune" // 只清理不再使用或长时间未使用的条目
+	GlobalCacheClear = "clear" // 清空整个缓存
+)
+
+// 全局缓存清理的实现方法
+const (
+	GlobalCacheMethodTool    = "tool"    // 调用工具自带的命令
+	GlobalCacheMethodEmulate = "emulate" // 删除长时间未使用的缓存条目，模拟工具的 prune
+	GlobalCacheMethodDelete  = "delete"  // 删除缓存目录中的所有内容
+)
+
+// GlobalCache 开发工具在项目之外的全局缓存，如 ~/.m2/repository
+type GlobalCache struct {
+	ID           string `json:"id"`           // 标识，如 "maven"
+	Name         string `json:"name"`         // 显示名称
+	Description  string `json:"description"`  // 描述
+	Path         string `json:"path"`         // 缓存目录
+	Exists       bool   `json:"exists"`       // 缓存目录是否存在
+	Size         int64  `json:"size"`         // 大小（字节），按配置的统计方式
+	ApparentSize int64  `json:"apparentSize"` // 文件内容大小之和（字节）
+	DiskSize     int64  `json:"diskSize"`     // 实际占用的磁盘空间（字节）
+	SizeReadable string `json:"sizeReadable"` // 可读的大小
+	FileCount    int    `json:"fileCount"`    // 文件数量
+	Tool         string `json:"tool"`         // 找到的工具命令路径，为空表示未安装
+	PruneCommand string `json:"pruneCommand"` // prune 时调用的工具命令，为空表示没有或工具未安装
+	ClearCommand string `json:"clearCommand"` // clear 时调用的工具命令，为空表示没有或工具未安装
+	CanPrune     bool   `json:"canPrune"`     // 是否支持 prune（调用工具或模拟）
+	Error        string `json:"error"`        // 统计大小失败的原因
+}
+
+// GlobalCacheCleanOptions 全局缓存的清理选项
+type GlobalCacheCleanOptions struct {
+	Mode       string `json:"mode"`       // 清理方式，见 GlobalCache* 常量，默认 prune
+	MaxAgeDays int    `json:"maxAgeDays"` // 模拟 prune 时清理多少天内没有使用的条目，默认 90
+	NoTool     bool   `json:"noTool"`     // 不调用工具命令，直接模拟 prune 或删除
+	DryRun     bool   `json:"dryRun"`     // 只计算将释放的空间，不删除
+}
+
+// GlobalCacheCleanResult 全局缓存的清理结果
+type GlobalCacheCleanResult struct {
+	ID            string `json:"id"`            // 缓存标识
+	Path          string `json:"path"`          // 缓存目录
+	Mode          string `json:"mode"`          // 清理方式
+	Method        string `json:"method"`        // 实现方法，见 GlobalCacheMethod* 常量
+	Command       string `json:"command"`       // 调用的工具命令
+	Output        string `json:"output"`        // 工具命令的输出（末尾部分）
+	RemovedCount  int    `json:"removedCount"`  // 模拟 prune 删除的条目数
+	FreedSize     int64  `json:"freedSize"`     // 释放（或将释放）的大小
+	FreedReadable string `json:"freedReadable"` // 可读的释放大小
+	DryRun        bool   `json:"dryRun"`        // 是否为模拟
+	Duration      int64  `json:"duration"`      // 耗时（毫秒）
 }
 
 // DefaultScanRules 返回默认的扫描规则
@@ -76,6 +372,7 @@ func DefaultScanRules() []ScanRule {
 			ProjectMarkers:    []string{"package.json"},
 			RequireMarkers:    true,
 			ExcludeFromGlobal: true,
+			MarkerScope:       MarkerScopeSibling,
 		},
 		{
 			Name:              "Python",
@@ -86,6 +383,10 @@ func DefaultScanRules() []ScanRule {
 			ProjectMarkers:    []string{"requirements.txt", "setup.py", "pyproject.toml", "Pipfile"},
 			RequireMarkers:    false,
 			ExcludeFromGlobal: true,
+			Verifiers: []TargetVerifier{
+				{Target: ".venv", Contains: []string{"pyvenv.cfg"}},
+				{Target: "venv", Contains: []string{"pyvenv.cfg"}},
+			},
 		},
 		{
 			Name:              "Maven",
@@ -96,6 +397,10 @@ func DefaultScanRules() []ScanRule {
 			ProjectMarkers:    []string{"pom.xml"},
 			RequireMarkers:    true,
 			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeDeclared,
+			Verifiers: []TargetVerifier{
+				{Target: "target", Contains: []string{"classes", "maven-status"}},
+			},
 		},
 		{
 			Name:              "Gradle",
@@ -106,6 +411,7 @@ func DefaultScanRules() []ScanRule {
 			ProjectMarkers:    []string{"build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"},
 			RequireMarkers:    true,
 			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeDeclared,
 		},
 		{
 			Name:              "Rust",
@@ -116,6 +422,10 @@ func DefaultScanRules() []ScanRule {
 			ProjectMarkers:    []string{"Cargo.toml"},
 			RequireMarkers:    true,
 			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeDeclared,
+			Verifiers: []TargetVerifier{
+				{Target: "target", Contains: []string{"CACHEDIR.TAG", ".rustc_info.json"}},
+			},
 		},
 		{
 			Name:              "Go",
@@ -126,6 +436,153 @@ func DefaultScanRules() []ScanRule {
 			ProjectMarkers:    []string{"go.mod"},
 			RequireMarkers:    true,
 			ExcludeFromGlobal: true,
+			MarkerScope:       MarkerScopeSibling,
+		},
+		{
+			Name:              ".NET",
+			Description:       ".NET 构建输出和中间文件目录",
+			TargetDirs:        []string{"bin", "obj"},
+			Enabled:           true,
+			Priority:          80,
+			ProjectMarkers:    []string{"*.csproj", "*.fsproj", "*.vbproj"},
+			RequireMarkers:    true,
+			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeSibling,
+			Verifiers: []TargetVerifier{
+				{Target: "bin", Contains: []string{"Debug", "Release"}},
+				{Target: "obj", Contains: []string{"project.assets.json", "Debug", "Release"}},
+			},
+		},
+		{
+			Name:              "CMake",
+			Description:       "CMake 生成文件和 CLion 构建目录",
+			TargetDirs:        []string{"CMakeFiles", "cmake-build-*"},
+			Enabled:           true,
+			Priority:          70,
+			ProjectMarkers:    []string{"CMakeLists.txt"},
+			RequireMarkers:    true,
+			ExcludeFromGlobal: false,
+			Verifiers: []TargetVerifier{
+				{Target: "CMakeFiles", Contains: []string{"cmake.check_cache", "CMakeDirectoryInformation.cmake"}},
+				{Target: "cmake-build-*", Contains: []string{"CMakeCache.txt"}},
+			},
+		},
+		{
+			Name:              "Swift",
+			Description:       "Swift Package Manager 和 Xcode 构建目录",
+			TargetDirs:        []string{".build", "DerivedData"},
+			Enabled:           true,
+			Priority:          80,
+			ProjectMarkers:    []string{"Package.swift", "*.xcodeproj", "*.xcworkspace"},
+			RequireMarkers:    true,
+			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeSibling,
+			Verifiers: []TargetVerifier{
+				{Target: ".build", Contains: []string{"debug", "release", "checkouts", "workspace-state.json"}},
+				{Target: "DerivedData", Contains: []string{"Build", "ModuleCache.noindex", "Index.noindex"}},
+			},
+		},
+		{
+			Name:              "Dart",
+			Description:       "Dart/Flutter 工具缓存和构建目录",
+			TargetDirs:        []string{".dart_tool", "build"},
+			Enabled:           true,
+			Priority:          80,
+			ProjectMarkers:    []string{"pubspec.yaml"},
+			RequireMarkers:    true,
+			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeSibling,
+			Verifiers: []TargetVerifier{
+				{Target: ".dart_tool", Contains: []string{"package_config.json"}},
+			},
+		},
+		{
+			Name:              "Elixir",
+			Description:       "Elixir Mix 构建目录和依赖",
+			TargetDirs:        []string{"_build", "deps"},
+			Enabled:           true,
+			Priority:          80,
+			ProjectMarkers:    []string{"mix.exs"},
+			RequireMarkers:    true,
+			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeSibling,
+			Verifiers: []TargetVerifier{
+				{Target: "_build", Contains: []string{"dev", "test", "prod"}},
+			},
+		},
+		{
+			Name:              "Haskell",
+			Description:       "Haskell Cabal 和 Stack 构建目录",
+			TargetDirs:        []string{"dist-newstyle", ".stack-work"},
+			Enabled:           true,
+			Priority:          80,
+			ProjectMarkers:    []string{"*.cabal", "cabal.project", "stack.yaml"},
+			RequireMarkers:    true,
+			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeSibling,
+		},
+		{
+			Name:              "Zig",
+			Description:       "Zig 构建缓存和输出目录",
+			TargetDirs:        []string{"zig-cache", ".zig-cache", "zig-out"},
+			Enabled:           true,
+			Priority:          80,
+			ProjectMarkers:    []string{"build.zig"},
+			RequireMarkers:    true,
+			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeSibling,
+		},
+		{
+			Name:              "Terraform",
+			Description:       "Terraform 下载的 provider 和模块",
+			TargetDirs:        []string{".terraform"},
+			Enabled:           true,
+			Priority:          80,
+			ProjectMarkers:    []string{"*.tf", "*.tf.json"},
+			RequireMarkers:    true,
+			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeSibling,
+			Verifiers: []TargetVerifier{
+				{Target: ".terraform", Contains: []string{"providers", "modules"}},
+			},
+		},
+		{
+			Name:              "PHP",
+			Description:       "PHP Composer 依赖目录",
+			TargetDirs:        []string{"vendor"},
+			Enabled:           true,
+			Priority:          70,
+			ProjectMarkers:    []string{"composer.json"},
+			RequireMarkers:    true,
+			ExcludeFromGlobal: true,
+			MarkerScope:       MarkerScopeSibling,
+			Verifiers: []TargetVerifier{
+				{Target: "vendor", Contains: []string{"autoload.php", "composer"}},
+			},
+		},
+		{
+			Name:              "Ruby",
+			Description:       "Ruby Bundler 本地安装目录",
+			TargetDirs:        []string{".bundle"},
+			Enabled:           true,
+			Priority:          70,
+			ProjectMarkers:    []string{"Gemfile"},
+			RequireMarkers:    true,
+			ExcludeFromGlobal: false,
+			MarkerScope:       MarkerScopeSibling,
+			Verifiers: []TargetVerifier{
+				// 只有 config 的 .bundle 保存的是项目的 Bundler 设置，不默认清理
+				{Target: ".bundle", Contains: []string{"ruby"}},
+			},
+		},
+		{
+			Name:              "Jupyter",
+			Description:       "Jupyter Notebook 自动保存的检查点",
+			TargetDirs:        []string{".ipynb_checkpoints"},
+			Enabled:           true,
+			Priority:          90,
+			RequireMarkers:    false,
+			ExcludeFromGlobal: false,
 		},
 		{
 			Name:              "Java IDE",
@@ -137,6 +594,15 @@ func DefaultScanRules() []ScanRule {
 			RequireMarkers:    false,
 			ExcludeFromGlobal: false,
 		},
+		{
+			Name:              "CACHEDIR.TAG",
+			Description:       "带有 CACHEDIR.TAG 标记的缓存目录（Cargo、ccache 等工具会写入）",
+			Enabled:           true,
+			Priority:          10,
+			RequireMarkers:    false,
+			ExcludeFromGlobal: false,
+			Detector:          DetectorCacheDirTag,
+		},
 	}
 }
 
@@ -155,8 +621,12 @@ func DefaultConfig() *Config {
 	return &Config{
 		ScanPaths:          []string{},
 		IgnorePatterns:     []string{},
+		ProtectedPaths:     []string{},
 		GlobalPathExcludes: DefaultGlobalPathExcludes(),
 		ScanRules:          DefaultScanRules(),
+		DeleteMode:         DeleteModePermanent,
+		SizeMode:           SizeModeApparent,
+		QuarantineDays:     7,
 		LastScanTime:       time.Time{},
 	}
 }
@@ -0,0 +1,124 @@
+package quarantine
+
+import (
+	"errors"
+	"fast-clean-x/backend/utils"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// 便于测试替换
+var (
+	rename       = os.Rename
+	removeSource = os.RemoveAll
+	removeData   = utils.RemoveAll // 删除隔离记录中的目录，只读目录先恢复写权限
+)
+
+// partialMoveError 目录已经完整复制到目标位置，但源目录只删除了一部分
+type partialMoveError struct {
+	src string
+	err error
+}
+
+func (e *partialMoveError) Error() string {
+	return fmt.Sprintf("copied %s, but the source was only partly removed: %v", e.src, e.err)
+}
+
+func (e *partialMoveError) Unwrap() error {
+	return e.err
+}
+
+// moveDir 移动目录，同一文件系统内直接重命名，跨文件系统时复制后删除源目录
+// 复制成功后删除源目录失败时返回 *partialMoveError，此时目标位置是唯一完整的副本
+func moveDir(src, dst string) error {
+	err := rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	if err := removeSource(src); err != nil {
+		return &partialMoveError{src: src, err: err}
+	}
+	return nil
+}
+
+// copyTree 递归复制目录，保留权限、符号链接和修改时间
+func copyTree(src, dst string) error {
+	// 目录先以可写权限创建，复制完成后再恢复原始权限
+	dirModes := make(map[string]fs.FileMode)
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.IsDir():
+			dirModes[target] = info.Mode().Perm()
+			return os.MkdirAll(target, 0700)
+		case d.Type().IsRegular():
+			if err := copyFile(path, target, info.Mode().Perm()); err != nil {
+				return err
+			}
+			return os.Chtimes(target, info.ModTime(), info.ModTime())
+		default:
+			// 跳过设备文件、管道等特殊文件
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for dir, mode := range dirModes {
+		if err := os.Chmod(dir, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile 复制单个文件
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
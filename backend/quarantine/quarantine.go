@@ -0,0 +1,307 @@
+package quarantine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	entryFile = "entry.json" // 隔离记录文件
+	dataDir   = "data"       // 被隔离的目录
+)
+
+// Store 隔离区，清理的目录先移到这里，保留期内可以恢复
+//
+// 目录结构：
+//
+//	<dir>/<id>/entry.json  隔离记录
+//	<dir>/<id>/data        被隔离的目录
+type Store struct {
+	dir      string
+	sizeMode string
+	mu       sync.Mutex
+}
+
+// New 创建指定目录的隔离区
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Open 打开默认隔离区（配置目录下的 quarantine 目录）
+func Open() (*Store, error) {
+	configDir, err := utils.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(filepath.Join(configDir, "quarantine")), nil
+}
+
+// SetSizeMode 设置记录大小的统计方式，见 models.SizeMode* 常量
+func (s *Store) SetSizeMode(mode string) {
+	s.sizeMode = mode
+}
+
+// Dir 返回隔离区目录
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Add 将扫描项移入隔离区
+// 跨文件系统移动时，如果复制成功但源目录只删除了一部分，保留隔离记录并同时返回记录和错误
+func (s *Store) Add(item models.ScanItem) (*models.QuarantineEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	absPath, err := filepath.Abs(item.Path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(absPath); err != nil {
+		return nil, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	entryDir := filepath.Join(s.dir, id)
+	if err := os.MkdirAll(entryDir, 0700); err != nil {
+		return nil, err
+	}
+
+	// 记录移入时的实际大小，扫描后目录可能已经变化
+	size := item.Size
+	if usage, err := utils.MeasureDir(absPath); err == nil {
+		size = usage.Size(s.sizeMode)
+	}
+
+	entry := &models.QuarantineEntry{
+		ID:            id,
+		OriginalPath:  absPath,
+		ProjectName:   item.ProjectName,
+		Type:          item.Type,
+		Size:          size,
+		SizeReadable:  utils.FormatSize(size),
+		QuarantinedAt: time.Now(),
+	}
+
+	// 先写记录再移动，避免移动成功但丢失原始路径
+	if err := writeEntry(entryDir, entry); err != nil {
+		os.RemoveAll(entryDir)
+		return nil, err
+	}
+
+	if err := moveDir(absPath, filepath.Join(entryDir, dataDir)); err != nil {
+		var partial *partialMoveError
+		if errors.As(err, &partial) {
+			// 隔离区中的副本是唯一完整的数据，不能删除
+			return entry, err
+		}
+		os.RemoveAll(entryDir)
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// KeepsRemoved 隔离区中的内容仍然占用空间，实现 cleaner.Keeper
+func (s *Store) KeepsRemoved() bool {
+	return true
+}
+
+// Remove 将扫描项移入隔离区，返回移入的大小，实现 cleaner.Remover
+// 源目录只删除了一部分时，返回已经从原位置移走的大小
+func (s *Store) Remove(item models.ScanItem) (int64, error) {
+	entry, err := s.Add(item)
+	if entry == nil {
+		return 0, err
+	}
+	if err != nil {
+		remaining, _ := utils.MeasureDir(entry.OriginalPath)
+		return max(entry.Size-remaining.Size(s.sizeMode), 0), err
+	}
+	return entry.Size, nil
+}
+
+// List 列出隔离区中的所有记录，按隔离时间倒序
+func (s *Store) List() ([]models.QuarantineEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dirEntries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []models.QuarantineEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.QuarantineEntry, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		entry, err := readEntry(filepath.Join(s.dir, dirEntry.Name()))
+		if err != nil {
+			// 记录损坏或未完成的隔离，跳过
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].QuarantinedAt.After(entries[j].QuarantinedAt)
+	})
+	return entries, nil
+}
+
+// Restore 将隔离的目录恢复到原始位置
+func (s *Store) Restore(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entryDir, err := s.entryDir(id)
+	if err != nil {
+		return err
+	}
+	entry, err := readEntry(entryDir)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(entry.OriginalPath); err == nil {
+		return fmt.Errorf("cannot restore, path already exists: %s", entry.OriginalPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.OriginalPath), 0755); err != nil {
+		return err
+	}
+
+	if err := moveDir(filepath.Join(entryDir, dataDir), entry.OriginalPath); err != nil {
+		return err
+	}
+	return os.RemoveAll(entryDir)
+}
+
+// Purge 永久删除指定的隔离记录，id 为空时清空整个隔离区
+// 清空时某条记录删除失败不影响其他记录，返回所有错误
+func (s *Store) Purge(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id != "" {
+		entryDir, err := s.entryDir(id)
+		if err != nil {
+			return err
+		}
+		return removeEntry(entryDir)
+	}
+
+	dirEntries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, dirEntry := range dirEntries {
+		if err := removeEntry(filepath.Join(s.dir, dirEntry.Name())); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return os.Remove(s.dir)
+}
+
+// PurgeExpired 永久删除超过保留期的隔离记录，返回删除的数量
+// 某条记录删除失败时继续删除其他记录，返回所有错误
+func (s *Store) PurgeExpired(retention time.Duration) (int, error) {
+	entries, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []error
+	deadline := time.Now().Add(-retention)
+	for _, entry := range entries {
+		if entry.QuarantinedAt.After(deadline) {
+			continue
+		}
+		if err := s.Purge(entry.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(errs...)
+}
+
+// removeEntry 删除一条隔离记录
+// 先删除被隔离的目录再删除记录文件，删除失败时记录仍然可以列出、再次清理或恢复
+func removeEntry(entryDir string) error {
+	if err := removeData(filepath.Join(entryDir, dataDir)); err != nil {
+		return err
+	}
+	return utils.RemoveAll(entryDir)
+}
+
+// entryDir 返回隔离记录所在目录，并校验 id 合法
+func (s *Store) entryDir(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || id == "." || id == ".." {
+		return "", fmt.Errorf("invalid quarantine id: %q", id)
+	}
+	entryDir := filepath.Join(s.dir, id)
+	if !utils.IsDirectory(entryDir) {
+		return "", fmt.Errorf("quarantine entry not found: %s", id)
+	}
+	return entryDir, nil
+}
+
+// newID 生成隔离记录 id，按时间排序且不会重复
+func newID() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(buf), nil
+}
+
+// readEntry 读取隔离记录
+func readEntry(entryDir string) (*models.QuarantineEntry, error) {
+	data, err := os.ReadFile(filepath.Join(entryDir, entryFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var entry models.QuarantineEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(filepath.Join(entryDir, dataDir)); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// writeEntry 写入隔离记录
+func writeEntry(entryDir string, entry *models.QuarantineEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(entryDir, entryFile), data, 0600)
+}
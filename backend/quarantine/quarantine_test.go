@@ -0,0 +1,243 @@
+package quarantine
+
+import (
+	"errors"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newTarget 创建一个带文件的目标目录
+func newTarget(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(path, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "lib", "index.js"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddAndRestore(t *testing.T) {
+	root := t.TempDir()
+	store := New(filepath.Join(root, "quarantine"))
+	target := filepath.Join(root, "web", "dist")
+	newTarget(t, target)
+
+	entry, err := store.Add(models.ScanItem{Path: target, Type: "Node.js", Size: 1, ProjectName: "web"})
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	if utils.PathExists(target) {
+		t.Fatal("target still exists after Add()")
+	}
+
+	entries, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != entry.ID || entries[0].OriginalPath != target || entries[0].Type != "Node.js" {
+		t.Fatalf("List() = %+v, want entry for %s", entries, target)
+	}
+
+	// 原路径被重新创建时不能覆盖
+	newTarget(t, target)
+	if err := store.Restore(entry.ID); err == nil {
+		t.Fatal("Restore() should fail when original path exists")
+	}
+	os.RemoveAll(target)
+
+	if err := store.Restore(entry.ID); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	if !utils.PathExists(filepath.Join(target, "lib", "index.js")) {
+		t.Fatal("restored directory is missing files")
+	}
+	if entries, _ := store.List(); len(entries) != 0 {
+		t.Fatalf("List() after restore = %+v, want empty", entries)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	root := t.TempDir()
+	store := New(filepath.Join(root, "quarantine"))
+
+	var ids []string
+	for _, name := range []string{"a", "b"} {
+		target := filepath.Join(root, name, "target")
+		newTarget(t, target)
+		entry, err := store.Add(models.ScanItem{Path: target})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, entry.ID)
+	}
+
+	if n, err := store.PurgeExpired(time.Hour); err != nil || n != 0 {
+		t.Fatalf("PurgeExpired(1h) = %d, %v; want 0, nil", n, err)
+	}
+
+	if err := store.Purge(ids[0]); err != nil {
+		t.Fatalf("Purge() error: %v", err)
+	}
+	if entries, _ := store.List(); len(entries) != 1 || entries[0].ID != ids[1] {
+		t.Fatalf("List() after purge = %+v", entries)
+	}
+
+	if n, err := store.PurgeExpired(0); err != nil || n != 1 {
+		t.Fatalf("PurgeExpired(0) = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestPurgeExpiredContinuesPastFailures(t *testing.T) {
+	root := t.TempDir()
+	store := New(filepath.Join(root, "quarantine"))
+	var ids []string
+	for _, name := range []string{"a", "b", "c"} {
+		target := filepath.Join(root, name, "dist")
+		newTarget(t, target)
+		entry, err := store.Add(models.ScanItem{Path: target, Size: 1})
+		if err != nil {
+			t.Fatalf("Add() error: %v", err)
+		}
+		ids = append(ids, entry.ID)
+	}
+
+	// 只读目录也要能删除
+	readOnly := filepath.Join(store.dir, ids[0], dataDir, "lib")
+	if err := os.Chmod(readOnly, 0555); err != nil {
+		t.Fatal(err)
+	}
+
+	failing := filepath.Join(store.dir, ids[1], dataDir)
+	removeData = func(path string) error {
+		if path == failing {
+			return errors.New("busy")
+		}
+		return utils.RemoveAll(path)
+	}
+	defer func() { removeData = utils.RemoveAll }()
+
+	n, err := store.PurgeExpired(0)
+	if err == nil || n != 2 {
+		t.Fatalf("PurgeExpired(0) = %d, %v; want 2 and an error", n, err)
+	}
+	entries, err := store.List()
+	if err != nil || len(entries) != 1 || entries[0].ID != ids[1] {
+		t.Fatalf("List() after failed purge = %+v, %v", entries, err)
+	}
+
+	if err := store.Purge(""); err == nil {
+		t.Fatal("Purge(\"\") should report the failing entry")
+	}
+	if !utils.PathExists(filepath.Join(store.dir, ids[1], entryFile)) {
+		t.Fatal("record removed while its data is still present")
+	}
+
+	removeData = utils.RemoveAll
+	if err := store.Purge(""); err != nil {
+		t.Fatalf("Purge(\"\") error: %v", err)
+	}
+	if utils.PathExists(store.dir) {
+		t.Fatal("quarantine directory still exists after Purge(\"\")")
+	}
+}
+
+func TestInvalidID(t *testing.T) {
+	store := New(t.TempDir())
+	for _, id := range []string{"", ".", "..", "../config", "a/b"} {
+		if err := store.Restore(id); err == nil {
+			t.Errorf("Restore(%q) should fail", id)
+		}
+		if id != "" {
+			if err := store.Purge(id); err == nil {
+				t.Errorf("Purge(%q) should fail", id)
+			}
+		}
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	newTarget(t, src)
+	if err := os.Symlink("lib/index.js", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(src, "lib"), 0555); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(filepath.Join(src, "lib"), 0755)
+
+	dst := filepath.Join(root, "dst")
+	if err := copyTree(src, dst); err != nil {
+		t.Fatalf("copyTree() error: %v", err)
+	}
+	defer os.Chmod(filepath.Join(dst, "lib"), 0755)
+
+	if data, err := os.ReadFile(filepath.Join(dst, "link")); err != nil || string(data) != "x" {
+		t.Fatalf("copied symlink = %q, %v", data, err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "lib"))
+	if err != nil || info.Mode().Perm() != 0555 {
+		t.Fatalf("copied dir mode = %v, %v; want 0555", info.Mode().Perm(), err)
+	}
+}
+
+// crossDevice 模拟跨文件系统移动，删除源目录时只删除其中的文件然后失败
+func crossDevice(t *testing.T) {
+	t.Helper()
+	rename = func(src, dst string) error {
+		return &os.LinkError{Op: "rename", Old: src, New: dst, Err: syscall.EXDEV}
+	}
+	removeSource = func(path string) error {
+		os.Remove(filepath.Join(path, "lib", "index.js"))
+		return errors.New("permission denied")
+	}
+	t.Cleanup(func() {
+		rename = os.Rename
+		removeSource = os.RemoveAll
+	})
+}
+
+func TestAddKeepsCopyWhenSourceRemovalFails(t *testing.T) {
+	crossDevice(t)
+	root := t.TempDir()
+	store := New(filepath.Join(root, "quarantine"))
+	target := filepath.Join(root, "web", "dist")
+	newTarget(t, target)
+
+	entry, err := store.Add(models.ScanItem{Path: target})
+	if err == nil || entry == nil {
+		t.Fatalf("Add() = %v, %v; want the entry and an error", entry, err)
+	}
+
+	// 隔离区中保留完整的副本和记录
+	entries, err := store.List()
+	if err != nil || len(entries) != 1 || entries[0].ID != entry.ID {
+		t.Fatalf("List() = %+v, %v; want the partially moved entry", entries, err)
+	}
+	data, err := os.ReadFile(filepath.Join(store.Dir(), entry.ID, dataDir, "lib", "index.js"))
+	if err != nil || string(data) != "x" {
+		t.Fatalf("quarantined copy = %q, %v", data, err)
+	}
+}
+
+func TestRemoveReportsPartiallyMovedSize(t *testing.T) {
+	crossDevice(t)
+	root := t.TempDir()
+	store := New(filepath.Join(root, "quarantine"))
+	store.SetSizeMode(models.SizeModeApparent)
+	target := filepath.Join(root, "web", "dist")
+	newTarget(t, target)
+
+	// 只有 index.js 从原位置删除了
+	freed, err := store.Remove(models.ScanItem{Path: target})
+	if err == nil || freed != 1 {
+		t.Fatalf("Remove() = %d, %v; want 1 byte freed and an error", freed, err)
+	}
+}
@@ -0,0 +1,252 @@
+package scanner
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fast-clean-x/backend/matcher"
+	"fast-clean-x/backend/utils"
+	"hash"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// cacheVersion 缓存格式版本，格式变化时旧缓存自动失效
+const cacheVersion = 4
+
+// Cache 增量扫描缓存，保存在配置目录下
+//
+// 缓存两类数据，都以目录路径为键，并用目录的修改时间和 inode 判断是否变化：
+//   - 普通目录的子目录列表和检测器读取的文件：目录本身没有变化时不再读取目录内容
+//   - 匹配目录的大小：目录树中所有目录都没有变化时直接使用上次的大小，
+//     只需要遍历目录而不需要对每个文件调用 Lstat
+//
+// 只修改文件内容而不增删文件时目录的修改时间不会变化，这种情况需要完整重新扫描
+type Cache struct {
+	path     string
+	previous cacheData // 上次扫描的缓存，只读
+	current  cacheData // 本次扫描访问到的条目
+	mu       sync.Mutex
+}
+
+// cacheData 缓存文件的内容
+type cacheData struct {
+	Version int                    `json:"version"`
+	Dirs    map[string]dirEntry    `json:"dirs"`
+	Targets map[string]targetEntry `json:"targets"`
+}
+
+// dirEntry 普通目录的缓存
+type dirEntry struct {
+	ModTime int64    `json:"mtime"`
+	Inode   uint64   `json:"inode"`
+	Subdirs []string `json:"subdirs"`
+	Files   []string `json:"files,omitempty"`
+}
+
+// targetEntry 匹配目录的缓存
+type targetEntry struct {
+	Signature uint64         `json:"signature"` // 目录树中所有目录的修改时间和 inode 的摘要
+	Usage     utils.DirUsage `json:"usage"`
+}
+
+// NewCache 创建使用指定文件的缓存
+func NewCache(path string) *Cache {
+	return &Cache{
+		path:     path,
+		previous: newCacheData(),
+		current:  newCacheData(),
+	}
+}
+
+// OpenCache 打开默认缓存（配置目录下的 scan-cache.json）并加载上次扫描的结果
+// 缓存文件损坏时视为没有缓存
+func OpenCache() (*Cache, error) {
+	configDir, err := utils.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	cache := NewCache(filepath.Join(configDir, "scan-cache.json"))
+	_ = cache.Load()
+	return cache, nil
+}
+
+// newCacheData 创建空的缓存内容
+func newCacheData() cacheData {
+	return cacheData{
+		Version: cacheVersion,
+		Dirs:    make(map[string]dirEntry),
+		Targets: make(map[string]targetEntry),
+	}
+}
+
+// Load 加载上次扫描的缓存，文件不存在时为空
+func (c *Cache) Load() error {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	previous := newCacheData()
+	if err := json.Unmarshal(data, &previous); err != nil {
+		return err
+	}
+	if previous.Version != cacheVersion || previous.Dirs == nil || previous.Targets == nil {
+		return nil
+	}
+	c.previous = previous
+	return nil
+}
+
+// Reset 丢弃上次扫描的缓存，下次扫描完整遍历所有目录
+func (c *Cache) Reset() {
+	c.previous = newCacheData()
+}
+
+// Save 保存本次扫描访问到的条目，没有访问到的目录（已删除或不再扫描）不会保留
+// 先写临时文件再重命名，避免写入中断导致文件损坏
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	data, err := json.Marshal(c.current)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path)
+}
+
+// list 返回目录的内容，目录没有变化时使用缓存
+func (c *Cache) list(path string) (dirListing, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return dirListing{}, err
+	}
+	modTime, inode := info.ModTime().UnixNano(), utils.Inode(info)
+
+	entry, ok := c.previous.Dirs[path]
+	if !ok || entry.ModTime != modTime || entry.Inode != inode {
+		listing, err := readListing(path)
+		if err != nil {
+			return dirListing{}, err
+		}
+		entry = dirEntry{ModTime: modTime, Inode: inode, Subdirs: listing.subdirs, Files: listing.files}
+	}
+
+	c.mu.Lock()
+	c.current.Dirs[path] = entry
+	c.mu.Unlock()
+	return dirListing{subdirs: entry.Subdirs, files: entry.Files}, nil
+}
+
+// usage 统计匹配目录的空间占用，目录树没有变化时使用缓存，返回是否来自缓存
+func (c *Cache) usage(ctx context.Context, path string) (utils.DirUsage, bool, error) {
+	signature, err := treeSignature(ctx, path)
+	if err != nil {
+		return utils.DirUsage{}, false, err
+	}
+
+	entry, cached := c.previous.Targets[path]
+	if !cached || entry.Signature != signature {
+		cached = false
+		usage, err := utils.MeasureDirContext(ctx, path)
+		if err != nil {
+			return usage, false, err
+		}
+		entry = targetEntry{Signature: signature, Usage: usage}
+	}
+
+	c.mu.Lock()
+	c.current.Targets[path] = entry
+	c.mu.Unlock()
+	return entry.Usage, cached, nil
+}
+
+// treeSignature 计算目录树中所有目录的相对路径、修改时间和 inode 的摘要
+// 增删或重命名任何文件都会改变所在目录的修改时间，从而改变摘要
+func treeSignature(ctx context.Context, root string) (uint64, error) {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return 0, err
+	}
+
+	h := fnv.New64a()
+	writeDirSignature(h, ".", info)
+
+	err = walkSubdirs(ctx, root, func(rel string, info fs.FileInfo) {
+		writeDirSignature(h, rel, info)
+	})
+	return h.Sum64(), err
+}
+
+// walkSubdirs 按名称顺序递归遍历 root 下的所有子目录（不跟随符号链接），只对目录调用 Lstat
+func walkSubdirs(ctx context.Context, root string, fn func(rel string, info fs.FileInfo)) error {
+	var walkDir func(dir, rel string) error
+	walkDir = func(dir, rel string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil // 忽略权限错误等，与统计大小时一致
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			childRel := filepath.Join(rel, entry.Name())
+			fn(childRel, info)
+			if err := walkDir(filepath.Join(dir, entry.Name()), childRel); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walkDir(root, "")
+}
+
+// writeDirSignature 将目录的相对路径、修改时间和 inode 写入摘要
+func writeDirSignature(h hash.Hash64, rel string, info fs.FileInfo) {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], uint64(info.ModTime().UnixNano()))
+	binary.LittleEndian.PutUint64(buf[8:], utils.Inode(info))
+	h.Write([]byte(rel))
+	h.Write([]byte{0})
+	h.Write(buf[:])
+}
+
+// readListing 读取目录下的子目录名（不包括指向目录的符号链接）和检测器读取的文件
+// 只根据 fs.DirEntry 的类型判断，不会对每一项调用 Lstat
+func readListing(path string) (dirListing, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return dirListing{}, err
+	}
+
+	listing := dirListing{subdirs: make([]string, 0)}
+	for _, entry := range entries {
+		switch {
+		case entry.IsDir():
+			listing.subdirs = append(listing.subdirs, entry.Name())
+		case entry.Type().IsRegular() && matcher.IsDetectorFile(entry.Name()):
+			listing.files = append(listing.files, entry.Name())
+		}
+	}
+	return listing, nil
+}
@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"context"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanWithCache(t *testing.T) {
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "scan-cache.json")
+	for _, project := range []string{"a", "b"} {
+		writeFile(t, filepath.Join(root, project, "package.json"), 0)
+		writeFile(t, filepath.Join(root, project, "node_modules", "index.js"), 100)
+	}
+
+	// scan 使用从文件加载的缓存扫描一次，完成后保存缓存
+	scan := func(full bool) *models.ScanResult {
+		t.Helper()
+		cache := NewCache(cachePath)
+		if err := cache.Load(); err != nil {
+			t.Fatalf("Load() error: %v", err)
+		}
+		if full {
+			cache.Reset()
+		}
+
+		s := New([]models.ScanRule{{Name: "Node.js", TargetDirs: []string{"node_modules"}, Enabled: true}}, nil, nil)
+		s.SetCache(cache)
+		go func() {
+			for range s.GetProgressChan() {
+			}
+		}()
+		defer s.Close()
+
+		result, err := s.Scan([]string{root})
+		if err != nil {
+			t.Fatalf("Scan() error: %v", err)
+		}
+		if err := cache.Save(); err != nil {
+			t.Fatalf("Save() error: %v", err)
+		}
+		return result
+	}
+
+	if result := scan(false); result.TotalCount != 2 || result.TotalSize != 200 || result.CachedCount != 0 {
+		t.Fatalf("first scan = %+v, want 2 items of 200 bytes and nothing cached", result)
+	}
+	if result := scan(false); result.TotalCount != 2 || result.TotalSize != 200 || result.CachedCount != 2 {
+		t.Fatalf("second scan = %+v, want both items from cache", result)
+	}
+
+	// 新增文件和新项目都能被发现，只有变化的目录重新统计
+	writeFile(t, filepath.Join(root, "a", "node_modules", "pkg", "index.js"), 50)
+	writeFile(t, filepath.Join(root, "c", "node_modules", "index.js"), 10)
+	result := scan(false)
+	if result.TotalCount != 3 || result.TotalSize != 260 || result.CachedCount != 1 {
+		t.Fatalf("scan after changes = %+v, want 3 items of 260 bytes with 1 cached", result)
+	}
+
+	// 匹配目录深层的变化（如构建输出写入 target/debug/deps）同样会重新统计
+	writeFile(t, filepath.Join(root, "a", "node_modules", "pkg", "lib", "deep", "index.js"), 1000)
+	if result := scan(false); result.TotalSize != 1260 || result.CachedCount != 2 {
+		t.Fatalf("scan after a deep change = %+v, want 1260 bytes with 2 cached", result)
+	}
+
+	if result := scan(true); result.TotalCount != 3 || result.TotalSize != 1260 || result.CachedCount != 0 {
+		t.Fatalf("full scan = %+v, want nothing cached", result)
+	}
+}
+
+// BenchmarkCacheUsage 对比重新统计匹配目录的大小与使用缓存（只对目录调用 Lstat）的耗时
+func BenchmarkCacheUsage(b *testing.B) {
+	// 约 800 个目录、3000 个文件的 node_modules
+	target := filepath.Join(b.TempDir(), "node_modules")
+	makeTree(b, target, 3, 7, 4)
+
+	b.Run("measure", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := utils.MeasureDirContext(context.Background(), target); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		cache := NewCache(filepath.Join(b.TempDir(), "scan-cache.json"))
+		if _, _, err := cache.usage(context.Background(), target); err != nil {
+			b.Fatal(err)
+		}
+		cache.previous = cache.current
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, cached, err := cache.usage(context.Background(), target); err != nil || !cached {
+				b.Fatalf("usage() cached = %v, error = %v", cached, err)
+			}
+		}
+	})
+}
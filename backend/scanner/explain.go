@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"fast-clean-x/backend/matcher"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Explain 对目录执行与扫描相同的完整判断，说明扫描时是否会把它作为扫描项以及原因
+// roots 为扫描路径，用于判断扫描能否访问到该目录，为空时不检查
+func (s *Scanner) Explain(path string, roots []string) (models.MatchExplanation, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return models.MatchExplanation{}, fmt.Errorf("invalid path %s: %w", path, err)
+	}
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return models.MatchExplanation{}, fmt.Errorf("failed to stat %s: %w", absPath, err)
+	}
+	if !info.IsDir() {
+		return models.MatchExplanation{}, fmt.Errorf("not a directory: %s", absPath)
+	}
+
+	exp := models.MatchExplanation{Path: absPath}
+	if len(roots) > 0 {
+		exp.Skipped = s.reachReason(absPath, roots)
+	}
+	if exp.Skipped == "" {
+		exp.Skipped = s.skipReason(absPath)
+	}
+
+	m, ok := s.match(absPath, nil, true)
+	exp.Decisions = m.decisions
+	if ok {
+		exp.Type = m.rule.Name
+		exp.Confidence = m.confidence
+	}
+	exp.Matched = ok && exp.Skipped == ""
+	return exp, nil
+}
+
+// reachReason 返回扫描这些路径时访问不到该目录的原因
+// 遍历不跟随符号链接，也不会进入被跳过或已经匹配的目录
+func (s *Scanner) reachReason(path string, roots []string) string {
+	for _, root := range roots {
+		root, err := filepath.Abs(root)
+		if err != nil || (path != root && !utils.IsUnder(path, root)) {
+			continue
+		}
+
+		if path == root {
+			return ""
+		}
+		dir := root
+		rel, _ := filepath.Rel(root, path)
+		for _, name := range strings.Split(rel, string(filepath.Separator)) {
+			if reason := s.skipReason(dir); reason != "" {
+				return fmt.Sprintf("上级目录 %s 被跳过：%s", dir, reason)
+			}
+			if m, ok := s.match(dir, nil, false); ok {
+				return fmt.Sprintf("上级目录 %s 已匹配 %s，扫描不会进入其中", dir, m.rule.Name)
+			}
+			dir = filepath.Join(dir, name)
+			if info, err := os.Lstat(dir); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				return fmt.Sprintf("%s 是符号链接，扫描不会跟随", dir)
+			}
+		}
+		return ""
+	}
+	return "不在扫描路径中"
+}
+
+// skipReason 返回跳过目录及其中内容的原因，不跳过时返回空字符串
+func (s *Scanner) skipReason(path string) string {
+	// 跳过版本控制目录和系统目录
+	if utils.ShouldSkipDir(path) {
+		return "版本控制或系统目录"
+	}
+
+	// 检查是否匹配忽略模式
+	for _, pattern := range s.ignorePatterns {
+		if utils.MatchPattern(path, []string{pattern}) {
+			return fmt.Sprintf("匹配忽略模式 %s", pattern)
+		}
+	}
+	return ""
+}
+
+// markerReason 说明规则的项目标识检查为什么没有通过
+func markerReason(rule *matcher.Rule) string {
+	markers := strings.Join(rule.ProjectMarkers, "、")
+	switch rule.MarkerScope {
+	case models.MarkerScopeSibling:
+		return fmt.Sprintf("目标目录所在的目录中没有项目标识 %s", markers)
+	case models.MarkerScopeWithin:
+		return fmt.Sprintf("上方 %d 层以内没有项目标识 %s", rule.MarkerDepth, markers)
+	case models.MarkerScopeDeclared:
+		return fmt.Sprintf("目标目录所在的目录中没有项目标识 %s，也不是构建文件声明的输出目录", markers)
+	default:
+		return fmt.Sprintf("上方没有项目标识 %s", markers)
+	}
+}
+
+// decisionReason 说明通过检查的规则为什么被选中或落选，与 match、selectBestRule 的选择顺序一致
+func decisionReason(rule models.ScanRule, conf int, winner models.ScanRule, best int, candidates []models.ScanRule) string {
+	if rule.Name != winner.Name {
+		switch {
+		case conf < best:
+			return fmt.Sprintf("置信度 %d 低于 %s 的 %d", conf, winner.Name, best)
+		case requiresMarkers(winner) && !requiresMarkers(rule):
+			return fmt.Sprintf("%s 验证了项目标识，优先于只匹配目录名的规则", winner.Name)
+		default:
+			return fmt.Sprintf("优先级 %d 不高于 %s 的 %d", rule.Priority, winner.Name, winner.Priority)
+		}
+	}
+
+	if len(candidates) == 1 {
+		return fmt.Sprintf("置信度 %d 最高", conf)
+	}
+	for _, other := range candidates {
+		if !requiresMarkers(other) && requiresMarkers(rule) {
+			return fmt.Sprintf("置信度 %d 最高，验证了项目标识", conf)
+		}
+	}
+	return fmt.Sprintf("置信度 %d 最高，优先级 %d 最高", conf, rule.Priority)
+}
+
+// requiresMarkers 判断规则是否验证项目标识，验证过的规则优先于只匹配目录名的规则
+func requiresMarkers(rule models.ScanRule) bool {
+	return rule.RequireMarkers && len(rule.ProjectMarkers) > 0
+}
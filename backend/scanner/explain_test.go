@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"fast-clean-x/backend/models"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	root := t.TempDir()
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{root}, elem...)...)
+	}
+
+	writeFile(t, join("api", "pom.xml"), 0)
+	writeFile(t, join("api", "target", "classes", "App.class"), 10)
+	writeFile(t, join("web", "package.json"), 0)
+	writeFile(t, join("web", "node_modules", "lib", "build", "index.js"), 10)
+	writeFile(t, join("svc", "go.mod"), 0)
+	writeFile(t, join("svc", "vendor", "mod.go"), 10)
+	writeFile(t, join("legacy", "out", "data.txt"), 10)
+
+	s := New(models.DefaultScanRules(), []string{"legacy"}, models.DefaultGlobalPathExcludes())
+	defer s.Close()
+
+	decision := func(exp models.MatchExplanation, rule string) models.RuleDecision {
+		t.Helper()
+		for _, d := range exp.Decisions {
+			if d.Rule == rule {
+				return d
+			}
+		}
+		t.Fatalf("no decision for %s in %+v", rule, exp.Decisions)
+		return models.RuleDecision{}
+	}
+	explain := func(path string) models.MatchExplanation {
+		t.Helper()
+		exp, err := s.Explain(path, []string{root})
+		if err != nil {
+			t.Fatalf("Explain(%s) error: %v", path, err)
+		}
+		return exp
+	}
+
+	// Maven 与 Rust 都匹配 target，只有 Maven 找到了项目标识
+	exp := explain(join("api", "target"))
+	if !exp.Matched || exp.Type != "Maven" || exp.Confidence != models.ConfidenceVerified {
+		t.Errorf("api/target = %+v", exp)
+	}
+	if d := decision(exp, "Maven"); !d.Selected || d.Reason == "" {
+		t.Errorf("Maven decision = %+v", d)
+	}
+	if d := decision(exp, "Rust"); d.Matched || !strings.Contains(d.Reason, "Cargo.toml") {
+		t.Errorf("Rust decision = %+v", d)
+	}
+
+	// node_modules 中的 build 不会被访问到
+	exp = explain(join("web", "node_modules", "lib", "build"))
+	if exp.Matched || !strings.Contains(exp.Skipped, "Node.js") {
+		t.Errorf("node_modules/lib/build = %+v", exp)
+	}
+
+	// 已禁用的规则也给出判断
+	exp = explain(join("svc", "vendor"))
+	if exp.Matched || decision(exp, "Go").Reason != "规则已禁用" {
+		t.Errorf("svc/vendor = %+v", exp)
+	}
+
+	// 匹配忽略模式的目录
+	exp = explain(join("legacy", "out"))
+	if exp.Matched || !strings.Contains(exp.Skipped, "匹配忽略模式 legacy") || exp.Type != "Java IDE" {
+		t.Errorf("legacy/out = %+v", exp)
+	}
+
+	// 扫描路径之外
+	exp, err := s.Explain(join("api", "target"), []string{join("web")})
+	if err != nil || exp.Matched || exp.Skipped == "" {
+		t.Errorf("outside scan paths = %+v, %v", exp, err)
+	}
+
+	if _, err := s.Explain(join("api", "pom.xml"), nil); err == nil {
+		t.Error("Explain(file) error = nil")
+	}
+}
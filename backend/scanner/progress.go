@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"fast-clean-x/backend/models"
+	"sync"
+	"time"
+)
+
+// progressInterval 扫描过程中发送进度的最小间隔
+const progressInterval = 100 * time.Millisecond
+
+// progressTracker 统计扫描进度
+//
+// 完成百分比按工作单元估算：开始扫描前预先统计每个扫描根目录下的一级目录，
+// 每个一级目录（以及根目录本身）算作一个单元，扫描完一个单元进度前进一格
+type progressTracker struct {
+	mu          sync.Mutex
+	currentPath string
+	dirCount    int   // 已扫描的目录数
+	matchCount  int   // 已找到的匹配数
+	totalSize   int64 // 已发现的总大小
+	totalUnits  int   // 工作单元总数
+	doneUnits   int   // 已完成的工作单元数
+	lastSent    time.Time
+}
+
+// addUnits 增加工作单元
+func (t *progressTracker) addUnits(n int) {
+	t.mu.Lock()
+	t.totalUnits += n
+	t.mu.Unlock()
+}
+
+// unitDone 完成一个工作单元
+func (t *progressTracker) unitDone() {
+	t.mu.Lock()
+	t.doneUnits++
+	t.mu.Unlock()
+}
+
+// visitDir 记录扫描到的目录，返回是否到了发送进度的时间
+func (t *progressTracker) visitDir(path string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.dirCount++
+	t.currentPath = path
+	if time.Since(t.lastSent) < progressInterval {
+		return false
+	}
+	t.lastSent = time.Now()
+	return true
+}
+
+// addMatch 记录找到的匹配
+func (t *progressTracker) addMatch(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.matchCount++
+	t.currentPath = path
+	t.lastSent = time.Now()
+}
+
+// addSize 记录计算完成的匹配大小
+func (t *progressTracker) addSize(size int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.totalSize += size
+	t.lastSent = time.Now()
+}
+
+// snapshot 返回当前进度，扫描结束前进度最多为 99
+func (t *progressTracker) snapshot(scanning bool) models.ScanProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	progress := models.ScanProgress{
+		CurrentPath:  t.currentPath,
+		ScannedCount: t.dirCount,
+		MatchedCount: t.matchCount,
+		TotalSize:    t.totalSize,
+		IsScanning:   scanning,
+		Progress:     100,
+	}
+	if scanning {
+		progress.Progress = 0
+		if t.totalUnits > 0 {
+			progress.Progress = min(t.doneUnits*100/t.totalUnits, 99)
+		}
+	}
+	return progress
+}
@@ -2,30 +2,42 @@ package scanner
 
 import (
 	"context"
+	"fast-clean-x/backend/matcher"
 	"fast-clean-x/backend/models"
 	"fast-clean-x/backend/utils"
+	"fmt"
 	"os"
-	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ErrCancelled 扫描被取消，可以用 errors.Is 判断，同时也匹配 context.Canceled
+var ErrCancelled = fmt.Errorf("scan cancelled: %w", context.Canceled)
+
 // Scanner 扫描器
 type Scanner struct {
-	rules              []models.ScanRule
+	rules              []*matcher.Rule
 	ignorePatterns     []string
 	globalPathExcludes []string
+	concurrency        int
+	sizeMode           string
+	cache              *Cache
+	cachedCount        atomic.Int64
+	tracker            *progressTracker
+	itemHandler        func(models.ScanItemEvent)
 	progressChan       chan models.ScanProgress
+	sendMu             sync.Mutex
 	mu                 sync.Mutex
 	ctx                context.Context
 	cancel             context.CancelFunc
 }
 
-// New 创建新的扫描器
+// New 创建新的扫描器，规则的目标目录模式在这里预先编译
 func New(rules []models.ScanRule, ignorePatterns []string, globalPathExcludes []string) *Scanner {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Scanner{
-		rules:              rules,
+		rules:              matcher.CompileRules(rules),
 		ignorePatterns:     ignorePatterns,
 		globalPathExcludes: globalPathExcludes,
 		progressChan:       make(chan models.ScanProgress, 100),
@@ -34,7 +46,33 @@ func New(rules []models.ScanRule, ignorePatterns []string, globalPathExcludes []
 	}
 }
 
+// SetConcurrency 设置同时遍历的目录数，小于等于 0 时使用 DefaultConcurrency
+func (s *Scanner) SetConcurrency(n int) {
+	s.concurrency = n
+}
+
+// SetSizeMode 设置大小统计方式，决定 ScanItem.Size 使用内容大小还是磁盘占用，见 models.SizeMode* 常量
+func (s *Scanner) SetSizeMode(mode string) {
+	s.sizeMode = mode
+}
+
+// SetCache 设置增量扫描缓存
+// 设置后没有变化的目录使用缓存的子目录列表和大小，扫描完成后由调用方决定是否 Save
+func (s *Scanner) SetCache(cache *Cache) {
+	s.cache = cache
+}
+
+// SetItemHandler 设置扫描项事件的处理函数
+// 找到匹配的目录时立即以 ScanItemFound 调用，大小计算完成后以 ScanItemUpdated 再次调用。
+// 处理函数会在多个工作协程中同时调用，需要自行保证并发安全
+func (s *Scanner) SetItemHandler(handler func(models.ScanItemEvent)) {
+	s.itemHandler = handler
+}
+
 // Scan 扫描指定路径
+// 找到的目录先交给单独的工作池计算大小，遍历不会因为计算大目录而阻塞。
+// 取消时立即停止所有遍历和大小计算，返回已找到的部分结果和 ErrCancelled，
+// 其中大小未计算完成的项目 SizePending 为 true
 func (s *Scanner) Scan(paths []string) (*models.ScanResult, error) {
 	result := &models.ScanResult{
 		Items:      make([]models.ScanItem, 0),
@@ -43,11 +81,38 @@ func (s *Scanner) Scan(paths []string) (*models.ScanResult, error) {
 		ScanTime:   time.Now(),
 	}
 
-	var wg sync.WaitGroup
+	// 预先统计工作单元，用于估算进度
+	s.tracker = &progressTracker{}
+	for _, path := range paths {
+		s.tracker.addUnits(countUnits(path))
+	}
+	s.sendProgress(true)
+
 	itemsChan := make(chan models.ScanItem, 100)
+	sizeJobs := make(chan models.ScanItem, 1000)
+
+	// 启动大小计算工作池
+	concurrency := s.concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency()
+	}
+	var sizeWg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		sizeWg.Add(1)
+		go func() {
+			defer sizeWg.Done()
+			for item := range sizeJobs {
+				if measured, ok := s.measure(item); ok {
+					itemsChan <- measured
+				}
+			}
+		}()
+	}
 
 	// 启动结果收集器
+	collectDone := make(chan struct{})
 	go func() {
+		defer close(collectDone)
 		for item := range itemsChan {
 			s.mu.Lock()
 			result.Items = append(result.Items, item)
@@ -57,108 +122,172 @@ func (s *Scanner) Scan(paths []string) (*models.ScanResult, error) {
 		}
 	}()
 
-	// 扫描每个路径
-	for _, path := range paths {
-		wg.Add(1)
-		go func(p string) {
-			defer wg.Done()
-			s.scanPath(p, itemsChan)
-		}(path)
+	// 使用工作池并行遍历所有扫描路径
+	var list func(path string) (dirListing, error)
+	if s.cache != nil {
+		list = s.cache.list
 	}
-
-	// 等待所有扫描完成
-	wg.Wait()
+	walk(s.ctx, paths, concurrency, list, func(path string, listing func() dirListing) bool {
+		return s.visit(path, listing, sizeJobs)
+	}, s.tracker.unitDone)
+	close(sizeJobs)
+	sizeWg.Wait()
 	close(itemsChan)
 
+	// 等待收集器处理完所有结果
+	<-collectDone
+
+	// 发送最终进度
+	s.sendProgress(false)
+	result.CachedCount = int(s.cachedCount.Load())
+
+	if s.ctx.Err() != nil {
+		result.Cancelled = true
+		return result, ErrCancelled
+	}
+	result.Complete = true
 	return result, nil
 }
 
-// scanPath 扫描单个路径
-func (s *Scanner) scanPath(rootPath string, itemsChan chan<- models.ScanItem) {
-	filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-		// 检查是否取消
-		select {
-		case <-s.ctx.Done():
-			return filepath.SkipDir
-		default:
+// countUnits 统计扫描根目录的工作单元数：根目录本身加上每个一级子目录
+func countUnits(rootPath string) int {
+	entries, err := os.ReadDir(rootPath)
+	if err != nil {
+		return 1
+	}
+	units := 1
+	for _, entry := range entries {
+		if entry.IsDir() {
+			units++
 		}
+	}
+	return units
+}
 
-		if err != nil {
-			return nil // 忽略错误，继续扫描
-		}
+// visit 处理遍历到的目录，返回是否继续进入该目录
+// 找到的匹配目录交给 sizeJobs 计算大小
+func (s *Scanner) visit(path string, listing func() dirListing, sizeJobs chan<- models.ScanItem) bool {
+	if s.skip(path) {
+		return false
+	}
 
-		if !info.IsDir() {
-			return nil
-		}
+	// 定期发送进度更新
+	if s.tracker.visitDir(path) {
+		s.sendProgress(true)
+	}
 
-		// 跳过版本控制目录和系统目录
-		if utils.ShouldSkipDir(path) {
-			return filepath.SkipDir
-		}
+	m, ok := s.match(path, listing, false)
+	if !ok {
+		return true
+	}
 
-		// 检查是否匹配忽略模式
-		if utils.MatchPattern(path, s.ignorePatterns) {
-			return filepath.SkipDir
-		}
+	// 找到匹配的目录
+	item := s.createScanItem(path, m)
+	if item != nil {
+		s.tracker.addMatch(path)
+		s.sendProgress(true)
+		s.emitItem(models.ScanItemFound, *item)
 
-		// 检查是否匹配扫描规则
-		dirName := filepath.Base(path)
+		sizeJobs <- *item
+	}
+	return false
+}
 
-		// 找到所有匹配的规则
-		var matchedRules []models.ScanRule
-		for _, rule := range s.rules {
-			if !rule.Enabled {
-				continue
-			}
+// skip 判断是否跳过目录及其中的内容
+func (s *Scanner) skip(path string) bool {
+	return s.skipReason(path) != ""
+}
 
-			for _, targetDir := range rule.TargetDirs {
-				if dirName == targetDir {
-					// 检查全局排除规则（智能上下文检测）
-					if s.shouldExcludeByPath(path, rule) {
-						continue
-					}
-
-					// 如果规则要求验证项目标识，检查是否能找到
-					if rule.RequireMarkers && len(rule.ProjectMarkers) > 0 {
-						if utils.FindNearestMarker(path, rule.ProjectMarkers) == "" {
-							// 找不到项目标识，跳过此规则
-							continue
-						}
-					}
-
-					matchedRules = append(matchedRules, rule)
-					break
-				}
-			}
+// ruleMatch 目录匹配的规则及置信度
+type ruleMatch struct {
+	rule       models.ScanRule
+	confidence int
+	decisions  []models.RuleDecision
+}
+
+// match 检查目录是否匹配扫描规则，同时记录目标目录模式匹配该目录的每条规则的判断
+// 有项目规则匹配时检测器规则不参与选择；有多个规则匹配时，只在置信度最高的规则中选择优先级最高的
+// listing 为遍历时读取目录内容的函数，检测器据此判断是否需要打开文件，为 nil 时检测器直接检查
+// explain 为 true 时已禁用的规则也参与判断并记录，但不会被选中
+func (s *Scanner) match(path string, listing func() dirListing, explain bool) (ruleMatch, bool) {
+	var hasFile func(name string) bool
+	if listing != nil {
+		hasFile = func(name string) bool { return listing().hasFile(name) }
+	}
+
+	var m ruleMatch
+	var candidates []models.ScanRule // 与 m.decisions 一一对应
+	for _, rule := range s.rules {
+		if !rule.Enabled && !explain {
+			continue
 		}
 
-		// 如果有多个规则匹配，选择优先级最高的
-		if len(matchedRules) > 0 {
-			bestRule := s.selectBestRule(path, matchedRules)
+		pattern, ok := rule.MatchListedTarget(path, hasFile)
+		if !ok {
+			continue
+		}
 
-			// 找到匹配的目录
-			item := s.createScanItem(path, bestRule.Name)
-			if item != nil {
-				itemsChan <- *item
+		decision := models.RuleDecision{Rule: rule.Name}
+		if !rule.Enabled {
+			decision.Reason = "规则已禁用"
+		} else if exclude := s.excludedBy(path, rule); exclude != "" {
+			// 检查全局排除规则（智能上下文检测）
+			decision.Reason = fmt.Sprintf("位于全局排除的 %s 中", exclude)
+		} else if _, ok := rule.MatchMarkers(path, pattern); !ok {
+			// 如果规则要求验证项目标识，检查项目标识是否在规则要求的位置
+			decision.Reason = markerReason(rule)
+		} else if conf, ok := confidence(rule, pattern, path); !ok {
+			// 检查目标目录的内容
+			decision.Reason = "必需的内容校验未通过"
+		} else {
+			decision.Matched = true
+			decision.Confidence = conf
+		}
+		m.decisions = append(m.decisions, decision)
+		candidates = append(candidates, rule.ScanRule)
+	}
 
-				// 发送进度更新
-				s.sendProgress(path)
-			}
-			return filepath.SkipDir
+	// 检测器只识别没有项目规则认领的目录，如 .pytest_cache 中也有 CACHEDIR.TAG，应归为 Python
+	projectMatched := false
+	for i, decision := range m.decisions {
+		projectMatched = projectMatched || (decision.Matched && candidates[i].Detector == "")
+	}
+	eligible := func(i int) bool {
+		return m.decisions[i].Matched && !(projectMatched && candidates[i].Detector != "")
+	}
+	for i, decision := range m.decisions {
+		if eligible(i) {
+			m.confidence = max(m.confidence, decision.Confidence)
 		}
+	}
 
-		return nil
-	})
-}
+	// 只在置信度最高的规则中选择
+	var best []models.ScanRule
+	for i, decision := range m.decisions {
+		if eligible(i) && decision.Confidence == m.confidence {
+			best = append(best, candidates[i])
+		}
+	}
+	if len(best) == 0 {
+		return m, false
+	}
 
-// createScanItem 创建扫描项
-func (s *Scanner) createScanItem(path string, ruleType string) *models.ScanItem {
-	// 计算目录大小
-	size, fileCount, err := utils.CalculateDirSize(path)
-	if err != nil {
-		return nil
+	// 如果有多个规则匹配，选择优先级最高的
+	m.rule = s.selectBestRule(path, best)
+	for i := range m.decisions {
+		if m.decisions[i].Matched && !eligible(i) {
+			m.decisions[i].Reason = fmt.Sprintf("项目规则 %s 匹配了该目录，检测器规则不参与选择", m.rule.Name)
+		} else if m.decisions[i].Matched {
+			m.decisions[i].Reason = decisionReason(candidates[i], m.decisions[i].Confidence, m.rule, m.confidence, best)
+			m.decisions[i].Selected = candidates[i].Name == m.rule.Name
+		}
 	}
+	return m, true
+}
 
+// createScanItem 创建扫描项，大小由 measure 稍后计算
+// 置信度低的扫描项默认不选中
+func (s *Scanner) createScanItem(path string, m ruleMatch) *models.ScanItem {
 	// 获取最后修改时间
 	info, err := os.Stat(path)
 	if err != nil {
@@ -173,22 +302,70 @@ func (s *Scanner) createScanItem(path string, ruleType string) *models.ScanItem
 		Path:         path,
 		ProjectPath:  projectPath,
 		ProjectName:  projectName,
-		Type:         ruleType,
-		Size:         size,
-		SizeReadable: utils.FormatSize(size),
-		FileCount:    fileCount,
+		Type:         m.rule.Name,
 		LastModified: info.ModTime(),
-		Selected:     true, // 默认选中
+		Selected:     m.confidence >= models.ConfidenceName,
+		Confidence:   m.confidence,
+		SizePending:  true,
+		Decisions:    m.decisions,
+	}
+}
+
+// measure 计算扫描项的大小，设置了缓存时目录树没有变化则使用缓存的大小
+// 扫描取消时立即停止，返回的扫描项保持 SizePending；目录已无法访问时返回 false
+func (s *Scanner) measure(item models.ScanItem) (models.ScanItem, bool) {
+	var usage utils.DirUsage
+	var err error
+	if s.cache != nil {
+		var cached bool
+		usage, cached, err = s.cache.usage(s.ctx, item.Path)
+		if cached {
+			s.cachedCount.Add(1)
+		}
+	} else {
+		usage, err = utils.MeasureDirContext(s.ctx, item.Path)
+	}
+	if s.ctx.Err() != nil {
+		return item, true
+	}
+	if err != nil {
+		s.emitItem(models.ScanItemRemoved, item)
+		return item, false
+	}
+
+	setUsage(&item, usage, s.sizeMode)
+
+	s.tracker.addSize(item.Size)
+	s.sendProgress(true)
+	s.emitItem(models.ScanItemUpdated, item)
+	return item, true
+}
+
+// setUsage 根据统计结果设置扫描项的大小
+func setUsage(item *models.ScanItem, usage utils.DirUsage, sizeMode string) {
+	item.Size = usage.Size(sizeMode)
+	item.ApparentSize = usage.ApparentSize
+	item.DiskSize = usage.DiskSize
+	item.SizeReadable = utils.FormatSize(item.Size)
+	item.FileCount = usage.FileCount
+	item.SizePending = false
+}
+
+// emitItem 发送扫描项事件
+func (s *Scanner) emitItem(kind string, item models.ScanItem) {
+	if s.itemHandler != nil {
+		s.itemHandler(models.ScanItemEvent{Kind: kind, Item: item})
 	}
 }
 
 // sendProgress 发送进度更新
-func (s *Scanner) sendProgress(currentPath string) {
+// 多个工作协程同时发送时加锁，保证进度按顺序递增
+func (s *Scanner) sendProgress(scanning bool) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
 	select {
-	case s.progressChan <- models.ScanProgress{
-		CurrentPath: currentPath,
-		IsScanning:  true,
-	}:
+	case s.progressChan <- s.tracker.snapshot(scanning):
 	default:
 		// 如果通道满了，跳过这次更新
 	}
@@ -211,36 +388,32 @@ func (s *Scanner) Close() {
 }
 
 // shouldExcludeByPath 检查路径是否应该被排除
-func (s *Scanner) shouldExcludeByPath(path string, rule models.ScanRule) bool {
+func (s *Scanner) shouldExcludeByPath(path string, rule *matcher.Rule) bool {
+	return s.excludedBy(path, rule) != ""
+}
+
+// excludedBy 返回排除该目录的全局排除项，没有排除时返回空字符串
+func (s *Scanner) excludedBy(path string, rule *matcher.Rule) string {
 	// 1. 检查全局排除
 	if !rule.ExcludeFromGlobal {
 		// 规则不豁免全局排除，检查所有全局排除项
 		for _, exclude := range s.globalPathExcludes {
 			if utils.Contains(path, exclude) {
-				return true
+				return exclude
 			}
 		}
 	} else {
 		// 规则豁免全局排除，但只豁免自己的目标目录
 		// 仍然检查其他全局排除项
 		for _, exclude := range s.globalPathExcludes {
-			// 检查这个排除项是否是当前规则的目标目录
-			isOwnTarget := false
-			for _, targetDir := range rule.TargetDirs {
-				if exclude == targetDir {
-					isOwnTarget = true
-					break
-				}
-			}
-
 			// 如果不是自己的目标目录，仍然应用全局排除
-			if !isOwnTarget && utils.Contains(path, exclude) {
-				return true
+			if !rule.IsOwnTarget(exclude) && utils.Contains(path, exclude) {
+				return exclude
 			}
 		}
 	}
 
-	return false
+	return ""
 }
 
 // selectBestRule 从多个匹配的规则中选择最佳的一个
@@ -255,7 +428,7 @@ func (s *Scanner) selectBestRule(path string, rules []models.ScanRule) models.Sc
 	var unverifiedRules []models.ScanRule
 
 	for _, rule := range rules {
-		if rule.RequireMarkers && len(rule.ProjectMarkers) > 0 {
+		if requiresMarkers(rule) {
 			verifiedRules = append(verifiedRules, rule)
 		} else {
 			unverifiedRules = append(unverifiedRules, rule)
@@ -1,7 +1,13 @@
 package scanner
 
 import (
+	"context"
+	"errors"
+	"fast-clean-x/backend/matcher"
 	"fast-clean-x/backend/models"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -110,10 +116,284 @@ func TestShouldExcludeByPath(t *testing.T) {
 			s := &Scanner{
 				globalPathExcludes: tt.globalPathExcludes,
 			}
-			result := s.shouldExcludeByPath(tt.path, tt.rule)
+			result := s.shouldExcludeByPath(tt.path, matcher.CompileRule(tt.rule))
 			if result != tt.expected {
 				t.Errorf("shouldExcludeByPath() = %v, want %v", result, tt.expected)
 			}
 		})
 	}
 }
+
+func TestScanProgress(t *testing.T) {
+	root := t.TempDir()
+	for _, project := range []string{"a", "b", "c"} {
+		writeFile(t, filepath.Join(root, project, "package.json"), 0)
+		writeFile(t, filepath.Join(root, project, "node_modules", "pkg", "index.js"), 100)
+		writeFile(t, filepath.Join(root, project, "src", "main.js"), 10)
+	}
+
+	s := New([]models.ScanRule{{
+		Name:           "Node.js",
+		TargetDirs:     []string{"node_modules"},
+		Enabled:        true,
+		ProjectMarkers: []string{"package.json"},
+		RequireMarkers: true,
+	}}, nil, nil)
+
+	events := make(chan []models.ScanProgress, 1)
+	go func() {
+		var progress []models.ScanProgress
+		for p := range s.GetProgressChan() {
+			progress = append(progress, p)
+		}
+		events <- progress
+	}()
+
+	result, err := s.Scan([]string{root})
+	s.Close()
+	progress := <-events
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if result.TotalCount != 3 || !result.Complete || result.Cancelled {
+		t.Fatalf("Scan() = %+v, want 3 items and a complete scan", result)
+	}
+
+	// 初始进度、每个匹配一次、最终进度
+	if len(progress) < 5 {
+		t.Fatalf("got %d progress events, want at least 5", len(progress))
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i].Progress < progress[i-1].Progress || progress[i].MatchedCount < progress[i-1].MatchedCount {
+			t.Errorf("progress went backwards: %+v -> %+v", progress[i-1], progress[i])
+		}
+	}
+	for _, p := range progress[:len(progress)-1] {
+		if !p.IsScanning || p.Progress >= 100 {
+			t.Errorf("intermediate progress = %+v, want scanning and below 100%%", p)
+		}
+	}
+
+	// 根目录、3 个项目目录及其中的 src 和 node_modules 目录
+	final := progress[len(progress)-1]
+	want := models.ScanProgress{CurrentPath: final.CurrentPath, ScannedCount: 10, MatchedCount: 3, TotalSize: 300, Progress: 100}
+	if final != want {
+		t.Errorf("final progress = %+v, want %+v", final, want)
+	}
+}
+
+// writeFile 创建指定大小的文件及其父目录
+func writeFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanCancelled(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a", "package.json"), 0)
+	writeFile(t, filepath.Join(root, "a", "node_modules", "index.js"), 100)
+
+	s := New([]models.ScanRule{{Name: "Node.js", TargetDirs: []string{"node_modules"}, Enabled: true}}, nil, nil)
+	go func() {
+		for range s.GetProgressChan() {
+		}
+	}()
+	defer s.Close()
+
+	s.Cancel()
+	result, err := s.Scan([]string{root})
+	if !errors.Is(err, ErrCancelled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Scan() error = %v, want ErrCancelled", err)
+	}
+	if result == nil || !result.Cancelled || result.Complete {
+		t.Fatalf("Scan() result = %+v, want partial result marked cancelled", result)
+	}
+	if result.TotalCount != 0 {
+		t.Errorf("Scan() found %d items after cancel, want 0", result.TotalCount)
+	}
+}
+
+func TestScanItemEvents(t *testing.T) {
+	root := t.TempDir()
+	for _, project := range []string{"a", "b"} {
+		writeFile(t, filepath.Join(root, project, "package.json"), 0)
+		writeFile(t, filepath.Join(root, project, "node_modules", "index.js"), 100)
+	}
+
+	s := New([]models.ScanRule{{Name: "Node.js", TargetDirs: []string{"node_modules"}, Enabled: true}}, nil, nil)
+	go func() {
+		for range s.GetProgressChan() {
+		}
+	}()
+	defer s.Close()
+
+	var mu sync.Mutex
+	events := make(map[string][]models.ScanItemEvent)
+	s.SetItemHandler(func(event models.ScanItemEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events[event.Item.Path] = append(events[event.Item.Path], event)
+	})
+
+	result, err := s.Scan([]string{root})
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got events for %d items, want 2", len(events))
+	}
+
+	// 每个目录先以大小待计算的状态发送，大小计算完成后再更新
+	for path, itemEvents := range events {
+		if len(itemEvents) != 2 {
+			t.Fatalf("%s got %d events, want 2", path, len(itemEvents))
+		}
+		found, updated := itemEvents[0], itemEvents[1]
+		if found.Kind != models.ScanItemFound || !found.Item.SizePending || found.Item.Size != 0 {
+			t.Errorf("first event = %+v, want found with pending size", found)
+		}
+		if updated.Kind != models.ScanItemUpdated || updated.Item.SizePending || updated.Item.Size != 100 {
+			t.Errorf("second event = %+v, want updated with size 100", updated)
+		}
+	}
+
+	for _, item := range result.Items {
+		if item.SizePending || item.Size != 100 {
+			t.Errorf("result item = %+v, want measured size", item)
+		}
+	}
+}
+
+func TestScanPatternTargets(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "lib", "mylib.egg-info", "PKG-INFO"), 10)
+	writeFile(t, filepath.Join(root, "cpp", "cmake-build-debug", "app"), 10)
+	writeFile(t, filepath.Join(root, "dotnet", "bin", "Debug", "app.dll"), 10)
+	writeFile(t, filepath.Join(root, "dotnet", "bin", "Tools", "tool.dll"), 10)
+	writeFile(t, filepath.Join(root, "dotnet", "obj", "Debug", "app.dll"), 10)
+
+	s := New([]models.ScanRule{
+		{Name: "Python", TargetDirs: []string{"*.egg-info"}, Enabled: true},
+		{Name: "CMake", TargetDirs: []string{"cmake-build-*"}, Enabled: true},
+		{Name: ".NET", TargetDirs: []string{"bin/Debug"}, Enabled: true},
+	}, nil, nil)
+	go func() {
+		for range s.GetProgressChan() {
+		}
+	}()
+	defer s.Close()
+
+	result, err := s.Scan([]string{root})
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	want := map[string]string{
+		filepath.Join(root, "lib", "mylib.egg-info"):    "Python",
+		filepath.Join(root, "cpp", "cmake-build-debug"): "CMake",
+		filepath.Join(root, "dotnet", "bin", "Debug"):   ".NET",
+	}
+	if len(result.Items) != len(want) {
+		t.Fatalf("Scan() found %d items, want %d: %+v", len(result.Items), len(want), result.Items)
+	}
+	for _, item := range result.Items {
+		if want[item.Path] != item.Type {
+			t.Errorf("unexpected item %s (%s)", item.Path, item.Type)
+		}
+	}
+}
+
+func TestScanDefaultRulesIgnoreNestedBuildDirs(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app", "build.gradle"), 0)
+	writeFile(t, filepath.Join(root, "app", "build", "libs", "app.jar"), 10)
+	writeFile(t, filepath.Join(root, "app", "src", "main", "java", "build", "Builder.java"), 10)
+
+	s := New(models.DefaultScanRules(), nil, models.DefaultGlobalPathExcludes())
+	go func() {
+		for range s.GetProgressChan() {
+		}
+	}()
+	defer s.Close()
+
+	result, err := s.Scan([]string{root})
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if len(result.Items) != 1 || result.Items[0].Path != filepath.Join(root, "app", "build") {
+		t.Errorf("Scan() = %+v, want only the Gradle build directory next to build.gradle", result.Items)
+	}
+}
+
+func TestScanBuiltinRuleCatalog(t *testing.T) {
+	root := t.TempDir()
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{root}, elem...)...)
+	}
+	files := []string{
+		"dotnet/App.csproj", "dotnet/bin/Debug/App.dll", "dotnet/obj/project.assets.json",
+		"cmake/CMakeLists.txt", "cmake/cmake-build-debug/CMakeCache.txt", "cmake/build/CMakeFiles/cmake.check_cache",
+		"swift/Package.swift", "swift/.build/debug/App",
+		"ios/App.xcodeproj/project.pbxproj", "ios/DerivedData/Build/App",
+		"flutter/pubspec.yaml", "flutter/.dart_tool/package_config.json", "flutter/build/web/main.dart.js",
+		"elixir/mix.exs", "elixir/_build/dev/app.beam", "elixir/deps/jason/mix.exs",
+		"haskell/app.cabal", "haskell/dist-newstyle/cache/plan.json",
+		"stack/stack.yaml", "stack/.stack-work/install/app",
+		"zig/build.zig", "zig/.zig-cache/h/abc", "zig/zig-out/bin/app",
+		"infra/main.tf", "infra/.terraform/providers/aws",
+		"php/composer.json", "php/vendor/autoload.php",
+		"ruby/Gemfile", "ruby/.bundle/ruby/3.3.0/gems/rake",
+		"notebooks/.ipynb_checkpoints/analysis-checkpoint.ipynb",
+		// 没有项目标识的同名目录不匹配
+		"plain/bin/tool", "plain/obj/file.o", "plain/deps/lib", "plain/.build/x", "plain/vendor/lib.php",
+	}
+	for _, file := range files {
+		writeFile(t, join(filepath.FromSlash(file)), 10)
+	}
+
+	s := New(models.DefaultScanRules(), nil, models.DefaultGlobalPathExcludes())
+	go func() {
+		for range s.GetProgressChan() {
+		}
+	}()
+	defer s.Close()
+
+	result, err := s.Scan([]string{root})
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	want := map[string]string{
+		"dotnet/bin": ".NET", "dotnet/obj": ".NET",
+		"cmake/cmake-build-debug": "CMake", "cmake/build/CMakeFiles": "CMake",
+		"swift/.build": "Swift", "ios/DerivedData": "Swift",
+		"flutter/.dart_tool": "Dart", "flutter/build": "Dart",
+		"elixir/_build": "Elixir", "elixir/deps": "Elixir",
+		"haskell/dist-newstyle": "Haskell", "stack/.stack-work": "Haskell",
+		"zig/.zig-cache": "Zig", "zig/zig-out": "Zig",
+		"infra/.terraform":             "Terraform",
+		"php/vendor":                   "PHP",
+		"ruby/.bundle":                 "Ruby",
+		"notebooks/.ipynb_checkpoints": "Jupyter",
+	}
+	got := make(map[string]string)
+	for _, item := range result.Items {
+		rel, _ := filepath.Rel(root, item.Path)
+		got[filepath.ToSlash(rel)] = item.Type
+	}
+	for path, rule := range want {
+		if got[path] != rule {
+			t.Errorf("%s type = %q, want %q", path, got[path], rule)
+		}
+	}
+	for path, rule := range got {
+		if _, ok := want[path]; !ok {
+			t.Errorf("unexpected item %s (%s)", path, rule)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package scanner
+
+import (
+	"fast-clean-x/backend/matcher"
+	"fast-clean-x/backend/models"
+)
+
+// confidence 根据规则的内容校验和项目标识计算匹配的置信度
+// 必需的内容校验没有通过时返回 false，目录不作为匹配
+func confidence(rule *matcher.Rule, pattern *matcher.Pattern, path string) (int, bool) {
+	switch rule.Verify(path, pattern) {
+	case matcher.VerifyPassed:
+		return models.ConfidenceVerified, true
+	case matcher.VerifyRejected:
+		return 0, false
+	case matcher.VerifyFailed:
+		return models.ConfidenceUnverified, true
+	}
+
+	if rule.RequireMarkers && len(rule.ProjectMarkers) > 0 {
+		return models.ConfidenceMarker, true
+	}
+	return models.ConfidenceName, true
+}
@@ -0,0 +1,161 @@
+package scanner
+
+import (
+	"fast-clean-x/backend/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanConfidence(t *testing.T) {
+	root := t.TempDir()
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{root}, elem...)...)
+	}
+
+	writeFile(t, join("maven", "pom.xml"), 0)
+	writeFile(t, join("maven", "target", "classes", "App.class"), 10)
+	writeFile(t, join("maven-empty", "pom.xml"), 0)
+	writeFile(t, join("maven-empty", "target", "notes.txt"), 10)
+	writeFile(t, join("rust", "Cargo.toml"), 0)
+	writeFile(t, join("rust", "target", "CACHEDIR.TAG"), 10)
+	writeFile(t, join("py", ".venv", "pyvenv.cfg"), 10)
+	writeFile(t, join("py", "venv", "data.csv"), 10)
+	writeFile(t, join("web", "package.json"), 0)
+	writeFile(t, join("web", "node_modules", "index.js"), 10)
+	writeFile(t, join("strict", "out", "data.txt"), 10)
+
+	rules := append(models.DefaultScanRules(), models.ScanRule{
+		Name:       "Strict",
+		TargetDirs: []string{"out"},
+		Enabled:    true,
+		Priority:   200,
+		Verifiers:  []models.TargetVerifier{{Target: "out", Contains: []string{"BUILD_MARKER"}, Required: true}},
+	})
+	s := New(rules, nil, models.DefaultGlobalPathExcludes())
+	go func() {
+		for range s.GetProgressChan() {
+		}
+	}()
+	defer s.Close()
+
+	result, err := s.Scan([]string{root})
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	want := map[string]int{
+		join("maven", "target"):       models.ConfidenceVerified,
+		join("maven-empty", "target"): models.ConfidenceUnverified,
+		join("rust", "target"):        models.ConfidenceVerified,
+		join("py", ".venv"):           models.ConfidenceVerified,
+		join("py", "venv"):            models.ConfidenceUnverified,
+		join("web", "node_modules"):   models.ConfidenceMarker,
+		// 必需的内容校验未通过的 Strict 规则不匹配，由 Java IDE 规则匹配
+		join("strict", "out"): models.ConfidenceName,
+	}
+	if len(result.Items) != len(want) {
+		t.Fatalf("Scan() found %d items, want %d: %+v", len(result.Items), len(want), result.Items)
+	}
+	for _, item := range result.Items {
+		conf, ok := want[item.Path]
+		if !ok {
+			t.Errorf("unexpected item %s", item.Path)
+			continue
+		}
+		if item.Confidence != conf {
+			t.Errorf("%s confidence = %d, want %d", item.Path, item.Confidence, conf)
+		}
+		if item.Selected != (conf >= models.ConfidenceName) {
+			t.Errorf("%s selected = %v with confidence %d", item.Path, item.Selected, conf)
+		}
+		if item.Path == join("strict", "out") && item.Type != "Java IDE" {
+			t.Errorf("%s type = %s, want Java IDE", item.Path, item.Type)
+		}
+	}
+}
+
+func TestScanCacheDirTag(t *testing.T) {
+	root := t.TempDir()
+	join := func(elem ...string) string {
+		return filepath.Join(append([]string{root}, elem...)...)
+	}
+	tag := func(dir, content string) {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "CACHEDIR.TAG"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const signature = "Signature: 8a477f597d28d172789f06886806bc55\n# This file is a cache directory tag.\n"
+	tag(join("tools", "ccache"), signature)
+	writeFile(t, join("tools", "ccache", "0", "stats"), 10)
+	tag(join("tools", "fake"), "not a cache\n")
+	writeFile(t, join("rust", "Cargo.toml"), 0)
+	tag(join("rust", "target"), signature)
+	// pytest 和 mypy 的缓存目录也带有 CACHEDIR.TAG，只按目录名匹配的项目规则置信度更低
+	writeFile(t, join("py", "pyproject.toml"), 0)
+	tag(join("py", ".pytest_cache"), signature)
+
+	scan := func(rules []models.ScanRule) map[string]models.ScanItem {
+		t.Helper()
+		s := New(rules, nil, models.DefaultGlobalPathExcludes())
+		go func() {
+			for range s.GetProgressChan() {
+			}
+		}()
+		defer s.Close()
+
+		result, err := s.Scan([]string{root})
+		if err != nil {
+			t.Fatalf("Scan() error: %v", err)
+		}
+		items := make(map[string]models.ScanItem)
+		for _, item := range result.Items {
+			items[item.Path] = item
+		}
+		return items
+	}
+
+	items := scan(models.DefaultScanRules())
+	if len(items) != 3 {
+		t.Fatalf("Scan() found %d items, want 3: %+v", len(items), items)
+	}
+	ccache, ok := items[join("tools", "ccache")]
+	if !ok || ccache.Type != "CACHEDIR.TAG" || ccache.Confidence != models.ConfidenceVerified || !ccache.Selected {
+		t.Errorf("tagged directory item = %+v", ccache)
+	}
+	// 同时匹配项目规则时以项目规则为准
+	item := items[join("rust", "target")]
+	if item.Type != "Rust" {
+		t.Errorf("rust target type = %q, want Rust", item.Type)
+	}
+	if pytest := items[join("py", ".pytest_cache")]; pytest.Type != "Python" || pytest.Confidence != models.ConfidenceName {
+		t.Errorf(".pytest_cache item = %+v, want the Python rule", pytest)
+	}
+	// 扫描项记录落选的规则及原因
+	var lost *models.RuleDecision
+	for i, d := range item.Decisions {
+		if d.Rule == "CACHEDIR.TAG" {
+			lost = &item.Decisions[i]
+		}
+	}
+	if lost == nil || !lost.Matched || lost.Selected || lost.Reason == "" {
+		t.Errorf("rust target decisions = %+v", item.Decisions)
+	}
+
+	// 关闭检测器后带标记的目录不再单独匹配
+	rules := models.DefaultScanRules()
+	for i := range rules {
+		if rules[i].Detector == models.DetectorCacheDirTag {
+			rules[i].Enabled = false
+		}
+	}
+	items = scan(rules)
+	if _, ok := items[join("tools", "ccache")]; ok || len(items) != 2 {
+		t.Errorf("Scan() with detector disabled = %+v", items)
+	}
+}
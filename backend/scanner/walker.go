@@ -0,0 +1,196 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// DefaultConcurrency 返回默认的遍历并发数
+// 遍历以等待文件系统为主，并发数可以超过 CPU 核数
+func DefaultConcurrency() int {
+	return min(max(runtime.NumCPU()*2, 4), 32)
+}
+
+// walkUnit 工作单元，单元内的目录全部遍历完成后进度前进一格
+type walkUnit struct {
+	pending int // 单元内已入队但未完成的目录数，由 dirQueue 的锁保护
+}
+
+// dirListing 遍历时读取的目录内容
+type dirListing struct {
+	subdirs []string // 子目录名，不包括指向目录的符号链接
+	files   []string // 检测器读取的文件中目录里存在的，见 matcher.IsDetectorFile
+}
+
+// hasFile 判断目录中是否有该文件，只能用于检测器读取的文件
+func (l dirListing) hasFile(name string) bool {
+	return slices.Contains(l.files, name)
+}
+
+// walkDir 待遍历的目录
+type walkDir struct {
+	path   string
+	unit   *walkUnit
+	isRoot bool
+}
+
+// dirQueue 待遍历的目录队列，所有目录遍历完成或关闭后 pop 返回 false
+type dirQueue struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	dirs     []walkDir
+	pending  int // 已入队但未完成的目录数
+	closed   bool
+	unitDone func()
+}
+
+// newDirQueue 创建目录队列，unitDone 在每个工作单元完成时调用
+func newDirQueue(unitDone func()) *dirQueue {
+	q := &dirQueue{unitDone: unitDone}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push 将目录加入队列
+func (q *dirQueue) push(dir walkDir) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	dir.unit.pending++
+	q.pending++
+	q.dirs = append(q.dirs, dir)
+	q.cond.Signal()
+}
+
+// pop 取出一个目录，队列为空时等待其他工作协程入队
+// 后进先出，遍历顺序接近深度优先，队列不会过长
+func (q *dirQueue) pop() (walkDir, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.dirs) == 0 && q.pending > 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.dirs) == 0 || q.closed {
+		return walkDir{}, false
+	}
+
+	dir := q.dirs[len(q.dirs)-1]
+	q.dirs = q.dirs[:len(q.dirs)-1]
+	return dir, true
+}
+
+// done 标记目录遍历完成（其子目录已入队）
+func (q *dirQueue) done(dir walkDir) {
+	q.mu.Lock()
+	dir.unit.pending--
+	unitFinished := dir.unit.pending == 0
+	q.pending--
+	if q.pending == 0 {
+		q.cond.Broadcast()
+	}
+	q.mu.Unlock()
+
+	if unitFinished && q.unitDone != nil {
+		q.unitDone()
+	}
+}
+
+// close 关闭队列，等待中的工作协程立即返回
+func (q *dirQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
+
+// walk 使用工作池并行遍历所有根目录
+//
+// 每个目录调用一次 visit，返回 false 时不再进入该目录。
+// 目录内容由 list 读取，为 nil 时使用 readListing：通过 os.ReadDir 读取目录项，
+// 只根据 fs.DirEntry 的类型判断是否为目录，不会对每一项调用 Lstat，也不会跟随符号链接。
+// visit 可以通过 listing 提前读取目录内容，进入目录时复用同一次读取的结果；没有调用时只在进入目录时读取。
+// 根目录本身和根目录下的每个一级子目录各算一个工作单元，完成时调用 unitDone。
+// ctx 取消后不再遍历新的目录，已开始的 visit 返回后 walk 立即返回。
+func walk(ctx context.Context, roots []string, workers int, list func(path string) (dirListing, error),
+	visit func(path string, listing func() dirListing) bool, unitDone func()) {
+	if workers <= 0 {
+		workers = DefaultConcurrency()
+	}
+	if list == nil {
+		list = readListing
+	}
+
+	q := newDirQueue(unitDone)
+	for _, root := range roots {
+		info, err := os.Lstat(root)
+		if err != nil || !info.IsDir() {
+			if unitDone != nil {
+				unitDone()
+			}
+			continue
+		}
+		q.push(walkDir{path: root, unit: &walkUnit{}, isRoot: true})
+	}
+
+	// 取消时关闭队列
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			q.close()
+		case <-finished:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				dir, ok := q.pop()
+				if !ok {
+					return
+				}
+				if ctx.Err() == nil {
+					listing := listOnce(dir.path, list)
+					if visit(dir.path, listing) {
+						walkChildren(q, dir, listing().subdirs)
+					}
+				}
+				q.done(dir)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// listOnce 返回只在第一次调用时读取目录内容的函数，读取失败时视为空目录，继续扫描
+func listOnce(path string, list func(path string) (dirListing, error)) func() dirListing {
+	var listing dirListing
+	listed := false
+	return func() dirListing {
+		if !listed {
+			listing, _ = list(path)
+			listed = true
+		}
+		return listing
+	}
+}
+
+// walkChildren 将目录下的子目录加入队列，根目录的每个子目录作为新的工作单元
+func walkChildren(q *dirQueue, dir walkDir, names []string) {
+	for _, name := range names {
+		unit := dir.unit
+		if dir.isRoot {
+			unit = &walkUnit{}
+		}
+		q.push(walkDir{path: filepath.Join(dir.path, name), unit: unit})
+	}
+}
@@ -0,0 +1,203 @@
+package scanner
+
+import (
+	"context"
+	"fast-clean-x/backend/models"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// makeTree 创建合成的目录树：每层 fanout 个子目录，每个目录 files 个文件，
+// 最底层的目录是带 package.json 和 node_modules 的项目
+func makeTree(tb testing.TB, root string, depth, fanout, files int) {
+	tb.Helper()
+	var build func(dir string, level int)
+	build = func(dir string, level int) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		for i := 0; i < files; i++ {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.txt", i)), []byte("x"), 0644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+		if level == depth {
+			if err := os.WriteFile(filepath.Join(dir, "package.json"), nil, 0644); err != nil {
+				tb.Fatal(err)
+			}
+			build(filepath.Join(dir, "node_modules"), depth+1)
+			return
+		}
+		if level > depth {
+			return
+		}
+		for i := 0; i < fanout; i++ {
+			build(filepath.Join(dir, fmt.Sprintf("dir%d", i)), level+1)
+		}
+	}
+	build(root, 0)
+}
+
+// serialWalk 原来的遍历方式：filepath.Walk 对每一项调用 Lstat，串行遍历
+func serialWalk(root string, visit func(path string) bool) {
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if !visit(path) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+func TestWalk(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, 2, 3, 2)
+	if err := os.Symlink(filepath.Join(root, "dir0"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 不进入 node_modules
+	skip := func(path string) bool { return filepath.Base(path) != "node_modules" }
+
+	var expected []string
+	serialWalk(root, func(path string) bool {
+		expected = append(expected, path)
+		return skip(path)
+	})
+
+	var mu sync.Mutex
+	var visited []string
+	var units atomic.Int32
+	walk(context.Background(), []string{root}, 4, nil, func(path string, _ func() dirListing) bool {
+		mu.Lock()
+		visited = append(visited, path)
+		mu.Unlock()
+		return skip(path)
+	}, func() { units.Add(1) })
+
+	sort.Strings(expected)
+	sort.Strings(visited)
+	if fmt.Sprint(visited) != fmt.Sprint(expected) {
+		t.Errorf("walk() visited %v\nwant %v", visited, expected)
+	}
+	if got, want := int(units.Load()), countUnits(root); got != want {
+		t.Errorf("walk() finished %d units, want %d", got, want)
+	}
+}
+
+func TestWalkListing(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"cache/a", "cache/b", "src"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"cache/CACHEDIR.TAG", "cache/data", "src/CACHEDIR.TAG.bak"} {
+		if err := os.WriteFile(filepath.Join(root, file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// visit 提前读取的目录内容只包含检测器读取的文件，进入目录时复用
+	var reads atomic.Int32
+	list := func(path string) (dirListing, error) {
+		reads.Add(1)
+		return readListing(path)
+	}
+	var mu sync.Mutex
+	tagged := make(map[string]bool)
+	walk(context.Background(), []string{root}, 4, list, func(path string, listing func() dirListing) bool {
+		mu.Lock()
+		tagged[path] = listing().hasFile("CACHEDIR.TAG")
+		mu.Unlock()
+		return true
+	}, nil)
+
+	if !tagged[filepath.Join(root, "cache")] || tagged[filepath.Join(root, "src")] || len(tagged) != 5 {
+		t.Errorf("walk() listings = %v", tagged)
+	}
+	if got := reads.Load(); got != 5 {
+		t.Errorf("walk() read directories %d times, want 5", got)
+	}
+}
+
+func TestWalkCancel(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, 3, 3, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var visited atomic.Int32
+	walk(ctx, []string{root}, 4, nil, func(path string, _ func() dirListing) bool {
+		if visited.Add(1) == 5 {
+			cancel()
+		}
+		return true
+	}, nil)
+
+	// 已开始的 visit 可能完成，但不会继续遍历剩余的目录
+	if n := visited.Load(); n >= 40 {
+		t.Errorf("walk() visited %d directories after cancel", n)
+	}
+}
+
+// benchmarkTree 基准测试使用的目录树，约 1400 个目录、5600 个文件
+func benchmarkTree(b *testing.B) string {
+	root := b.TempDir()
+	makeTree(b, root, 4, 5, 4)
+	return root
+}
+
+func BenchmarkWalk(b *testing.B) {
+	root := benchmarkTree(b)
+	visit := func(path string) bool { return true }
+
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			serialWalk(root, visit)
+		}
+	})
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("parallel-%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				walk(context.Background(), []string{root}, workers, nil, func(path string, _ func() dirListing) bool {
+					return visit(path)
+				}, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	root := benchmarkTree(b)
+	rules := []models.ScanRule{{
+		Name:           "Node.js",
+		TargetDirs:     []string{"node_modules"},
+		Enabled:        true,
+		ProjectMarkers: []string{"package.json"},
+		RequireMarkers: true,
+	}}
+
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s := New(rules, nil, nil)
+				s.SetConcurrency(workers)
+				go func() {
+					for range s.GetProgressChan() {
+					}
+				}()
+				if _, err := s.Scan([]string{root}); err != nil {
+					b.Fatal(err)
+				}
+				s.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fast-clean-x/backend/models"
+	"fast-clean-x/backend/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// targetWatchDepth 匹配目录内部监听的层数
+	// 匹配目录可能包含大量子目录（如 node_modules），只监听前几层，
+	// 足以发现安装依赖、构建输出等常见变化，同时避免耗尽系统的监听数量限制
+	targetWatchDepth = 2
+
+	// defaultSettleDelay 匹配目录最后一次变化后等待的时间，之后才重新统计大小
+	// 避免安装依赖、构建等持续写入的过程中反复统计
+	defaultSettleDelay = time.Second
+)
+
+// Watcher 监听扫描路径的文件系统变化，保持扫描结果实时更新
+//
+// 新建的匹配目录以 ScanItemFound 发送，大小变化以 ScanItemUpdated 发送，
+// 删除或移走的目录以 ScanItemRemoved 发送，事件通过扫描器的 SetItemHandler 处理。
+// 只修改文件内容的变化只在匹配目录的前 targetWatchDepth 层内能被发现
+type Watcher struct {
+	scanner *Scanner
+	paths   []string
+	fsw     *fsnotify.Watcher
+	items   map[string]models.ScanItem // 以路径为键的当前结果
+	dirty   map[string]time.Time       // 需要重新统计大小的匹配目录及其最后变化时间
+	settle  time.Duration
+	updated time.Time
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// Watch 开始监听扫描路径，使用扫描器的规则、忽略模式和大小统计方式
+//
+// seed 为上次扫描的结果（可以为 nil），其中仍然存在的目录不会重新统计大小。
+// 启动时遍历一次所有扫描路径，seed 中没有的匹配目录以 ScanItemFound 发送，
+// seed 中已不存在的目录以 ScanItemRemoved 发送
+func (s *Scanner) Watch(paths []string, seed *models.ScanResult) (*Watcher, error) {
+	w := newWatcher(s, seed)
+	if err := w.start(paths); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// newWatcher 创建监听器
+func newWatcher(s *Scanner, seed *models.ScanResult) *Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{
+		scanner: s,
+		items:   make(map[string]models.ScanItem),
+		dirty:   make(map[string]time.Time),
+		settle:  defaultSettleDelay,
+		updated: time.Now(),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	if seed != nil {
+		for _, item := range seed.Items {
+			w.items[item.Path] = item
+		}
+	}
+	return w
+}
+
+// start 监听扫描路径并启动事件处理
+func (w *Watcher) start(paths []string) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	w.fsw = fsw
+	w.paths = paths
+
+	for _, path := range paths {
+		if err := fsw.Add(path); err != nil {
+			fsw.Close()
+			return err
+		}
+	}
+	w.sync()
+
+	go w.run()
+	return nil
+}
+
+// Close 停止监听
+func (w *Watcher) Close() {
+	w.cancel()
+	<-w.done
+}
+
+// Result 返回当前的扫描结果
+func (w *Watcher) Result() *models.ScanResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	result := &models.ScanResult{
+		Items:    make([]models.ScanItem, 0, len(w.items)),
+		ScanTime: w.updated,
+		Complete: true,
+	}
+	for _, item := range w.items {
+		result.Items = append(result.Items, item)
+		result.TotalSize += item.Size
+		result.TotalCount++
+	}
+	return result
+}
+
+// run 处理文件系统事件，定期重新统计发生变化的匹配目录
+func (w *Watcher) run() {
+	defer close(w.done)
+	defer w.fsw.Close()
+
+	ticker := time.NewTicker(w.settle / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			// 事件队列溢出时丢失了部分变化，重新遍历所有扫描路径
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				w.sync()
+			}
+		case now := <-ticker.C:
+			w.measureSettled(now)
+		}
+	}
+}
+
+// sync 遍历所有扫描路径，监听所有目录并与当前结果对比
+func (w *Watcher) sync() {
+	found := make(map[string]bool)
+	var mu sync.Mutex
+	walk(w.ctx, w.paths, 0, nil, func(path string, listing func() dirListing) bool {
+		if isTarget := w.discover(path, listing); isTarget {
+			mu.Lock()
+			found[path] = true
+			mu.Unlock()
+			return false
+		}
+		return !w.scanner.skip(path)
+	}, nil)
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	// 移除已经不存在的目录
+	w.mu.Lock()
+	var removed []models.ScanItem
+	for path, item := range w.items {
+		if !found[path] {
+			removed = append(removed, item)
+			delete(w.items, path)
+			delete(w.dirty, path)
+		}
+	}
+	if len(removed) > 0 {
+		w.updated = time.Now()
+	}
+	w.mu.Unlock()
+
+	for _, item := range removed {
+		w.scanner.emitItem(models.ScanItemRemoved, item)
+	}
+}
+
+// discover 处理新发现的目录：匹配规则时加入结果并返回 true，否则监听该目录
+func (w *Watcher) discover(path string, listing func() dirListing) bool {
+	if w.scanner.skip(path) {
+		return false
+	}
+
+	m, ok := w.scanner.match(path, listing, false)
+	if !ok {
+		_ = w.fsw.Add(path) // 超出系统监听数量限制时忽略，该目录下的变化不会被发现
+		return false
+	}
+	w.watchTarget(path, 0)
+
+	w.mu.Lock()
+	_, known := w.items[path]
+	w.mu.Unlock()
+	if known {
+		return true
+	}
+
+	item := w.scanner.createScanItem(path, m)
+	if item == nil {
+		return true
+	}
+	w.mu.Lock()
+	w.items[path] = *item
+	w.dirty[path] = time.Time{} // 新目录立即统计大小
+	w.updated = time.Now()
+	w.mu.Unlock()
+	w.scanner.emitItem(models.ScanItemFound, *item)
+	return true
+}
+
+// watchTarget 监听匹配目录内部的前 targetWatchDepth 层
+func (w *Watcher) watchTarget(path string, depth int) {
+	_ = w.fsw.Add(path)
+	if depth >= targetWatchDepth {
+		return
+	}
+	listing, err := readListing(path)
+	if err != nil {
+		return
+	}
+	for _, name := range listing.subdirs {
+		w.watchTarget(filepath.Join(path, name), depth+1)
+	}
+}
+
+// handle 处理一个文件系统事件
+func (w *Watcher) handle(event fsnotify.Event) {
+	path := event.Name
+
+	// 匹配目录或其内部的变化
+	if target, depth, ok := w.targetOf(path); ok {
+		if depth == 0 && event.Has(fsnotify.Remove|fsnotify.Rename) {
+			w.removeUnder(path)
+			return
+		}
+		if depth > 0 && depth <= targetWatchDepth && event.Has(fsnotify.Create) && isDir(path) {
+			w.watchTarget(path, depth)
+		}
+		w.markDirty(target)
+		return
+	}
+
+	switch {
+	case event.Has(fsnotify.Create):
+		if !isDir(path) {
+			return
+		}
+		// 新建或移入的目录可能已经包含匹配目录（如克隆的仓库）
+		walk(w.ctx, []string{path}, 0, nil, func(dir string, listing func() dirListing) bool {
+			if w.discover(dir, listing) {
+				return false
+			}
+			return !w.scanner.skip(dir)
+		}, nil)
+	case event.Has(fsnotify.Remove | fsnotify.Rename):
+		w.removeUnder(path)
+	}
+}
+
+// targetOf 查找路径所在的匹配目录，返回匹配目录和路径相对它的层数
+func (w *Watcher) targetOf(path string) (string, int, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for depth, current := 0, path; ; depth++ {
+		if _, ok := w.items[current]; ok {
+			return current, depth, true
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", 0, false
+		}
+		current = parent
+	}
+}
+
+// removeUnder 移除路径本身及其中的所有匹配目录，并停止监听其中的目录
+func (w *Watcher) removeUnder(path string) {
+	prefix := path + string(filepath.Separator)
+
+	// 移走的目录在 inotify 中仍然被监听，需要手动移除
+	// 只有被监听的目录才需要检查其中的子目录，删除普通文件时直接跳过
+	if w.fsw.Remove(path) == nil {
+		for _, watched := range w.fsw.WatchList() {
+			if strings.HasPrefix(watched, prefix) {
+				_ = w.fsw.Remove(watched)
+			}
+		}
+	}
+
+	w.mu.Lock()
+	var removed []models.ScanItem
+	for itemPath, item := range w.items {
+		if itemPath == path || strings.HasPrefix(itemPath, prefix) {
+			removed = append(removed, item)
+			delete(w.items, itemPath)
+			delete(w.dirty, itemPath)
+		}
+	}
+	if len(removed) > 0 {
+		w.updated = time.Now()
+	}
+	w.mu.Unlock()
+
+	for _, item := range removed {
+		w.scanner.emitItem(models.ScanItemRemoved, item)
+	}
+}
+
+// markDirty 记录匹配目录发生了变化，稍后重新统计大小
+func (w *Watcher) markDirty(path string) {
+	w.mu.Lock()
+	w.dirty[path] = time.Now()
+	w.mu.Unlock()
+}
+
+// measureSettled 重新统计已经停止变化的匹配目录
+func (w *Watcher) measureSettled(now time.Time) {
+	w.mu.Lock()
+	var paths []string
+	for path, changed := range w.dirty {
+		if now.Sub(changed) >= w.settle {
+			paths = append(paths, path)
+			delete(w.dirty, path)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, path := range paths {
+		usage, err := utils.MeasureDirContext(w.ctx, path)
+		if w.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			w.removeUnder(path)
+			continue
+		}
+
+		w.mu.Lock()
+		item, ok := w.items[path]
+		if ok {
+			setUsage(&item, usage, w.scanner.sizeMode)
+			if info, err := os.Stat(path); err == nil {
+				item.LastModified = info.ModTime()
+			}
+			w.items[path] = item
+			w.updated = time.Now()
+		}
+		w.mu.Unlock()
+		if ok {
+			w.scanner.emitItem(models.ScanItemUpdated, item)
+		}
+	}
+}
+
+// isDir 判断路径是否为目录，不跟随符号链接
+func isDir(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"fast-clean-x/backend/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	root := t.TempDir()
+	nodeModulesA := filepath.Join(root, "a", "node_modules")
+	nodeModulesB := filepath.Join(root, "b", "node_modules")
+	writeFile(t, filepath.Join(nodeModulesA, "index.js"), 100)
+
+	s := New([]models.ScanRule{{Name: "Node.js", TargetDirs: []string{"node_modules"}, Enabled: true}}, nil, nil)
+	events := make(chan models.ScanItemEvent, 100)
+	s.SetItemHandler(func(event models.ScanItemEvent) {
+		events <- event
+	})
+
+	w := newWatcher(s, nil)
+	w.settle = 20 * time.Millisecond
+	if err := w.start([]string{root}); err != nil {
+		t.Fatalf("start() error: %v", err)
+	}
+	defer w.Close()
+
+	// waitFor 等待指定目录的指定事件
+	waitFor := func(kind, path string, size int64) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case event := <-events:
+				if event.Kind == kind && event.Item.Path == path && (kind != models.ScanItemUpdated || event.Item.Size == size) {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %s event of %s", kind, path)
+			}
+		}
+	}
+
+	// 启动时发现已有的目录
+	waitFor(models.ScanItemFound, nodeModulesA, 0)
+	waitFor(models.ScanItemUpdated, nodeModulesA, 100)
+
+	// 新建的项目
+	writeFile(t, filepath.Join(nodeModulesB, "index.js"), 10)
+	waitFor(models.ScanItemFound, nodeModulesB, 0)
+	waitFor(models.ScanItemUpdated, nodeModulesB, 10)
+
+	// 已有目录变大
+	writeFile(t, filepath.Join(nodeModulesA, "pkg", "index.js"), 50)
+	waitFor(models.ScanItemUpdated, nodeModulesA, 150)
+
+	// 目录被删除
+	if err := os.RemoveAll(nodeModulesB); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(models.ScanItemRemoved, nodeModulesB, 0)
+
+	result := w.Result()
+	if result.TotalCount != 1 || result.TotalSize != 150 || result.Items[0].Path != nodeModulesA {
+		t.Errorf("Result() = %+v, want only %s with 150 bytes", result, nodeModulesA)
+	}
+}
+
+func TestWatchSeed(t *testing.T) {
+	root := t.TempDir()
+	nodeModules := filepath.Join(root, "a", "node_modules")
+	writeFile(t, filepath.Join(nodeModules, "index.js"), 100)
+	gone := filepath.Join(root, "gone", "node_modules")
+
+	s := New([]models.ScanRule{{Name: "Node.js", TargetDirs: []string{"node_modules"}, Enabled: true}}, nil, nil)
+	var events []models.ScanItemEvent
+	s.SetItemHandler(func(event models.ScanItemEvent) {
+		events = append(events, event)
+	})
+
+	w := newWatcher(s, &models.ScanResult{Items: []models.ScanItem{
+		{Path: nodeModules, Size: 100},
+		{Path: gone, Size: 10},
+	}})
+	if err := w.start([]string{root}); err != nil {
+		t.Fatalf("start() error: %v", err)
+	}
+	w.Close()
+
+	// 上次扫描中仍然存在的目录不会重新发送，已不存在的目录被移除
+	if len(events) != 1 || events[0].Kind != models.ScanItemRemoved || events[0].Item.Path != gone {
+		t.Errorf("events = %+v, want only removal of %s", events, gone)
+	}
+	if result := w.Result(); result.TotalCount != 1 || result.TotalSize != 100 {
+		t.Errorf("Result() = %+v, want the seeded item", result)
+	}
+}
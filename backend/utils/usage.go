@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"fast-clean-x/backend/models"
+	"os"
+	"path/filepath"
+)
+
+// DirUsage 目录的空间占用
+type DirUsage struct {
+	ApparentSize int64 // 文件内容大小之和，与 ls 显示的一致
+	DiskSize     int64 // 实际分配的磁盘空间（包括目录本身），即删除目录能释放的空间，见 SharedSize
+	SharedSize   int64 // 还有硬链接位于目录外的文件占用的磁盘空间，删除目录不会释放，不计入 DiskSize
+	FileCount    int   // 文件数量，同一文件的多个硬链接只计算一次
+}
+
+// Size 按统计方式返回大小，见 models.SizeMode* 常量
+func (u DirUsage) Size(mode string) int64 {
+	if mode == models.SizeModeDisk {
+		return u.DiskSize
+	}
+	return u.ApparentSize
+}
+
+// fileID 文件的唯一标识（设备号和 inode），用于硬链接去重
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// Inode 返回文件的 inode 号，用于判断目录是否被替换，不支持的平台返回 0
+func Inode(info os.FileInfo) uint64 {
+	_, id, _ := fileUsage(info)
+	return id.ino
+}
+
+// UsageCounter 逐个累计文件的空间占用，同一文件的多个硬链接只计算一次
+// 有多个硬链接的文件先计入 SharedSize，所有链接都累计过后才移到 DiskSize，
+// 例如链接到 pnpm 全局存储的 node_modules 删除后不会释放这部分空间
+type UsageCounter struct {
+	Usage DirUsage
+	seen  map[fileID]uint64 // 有多个硬链接的文件已累计的链接数量
+}
+
+// Add 累计一个文件或目录的空间占用
+func (c *UsageCounter) Add(info os.FileInfo) {
+	disk, id, links := fileUsage(info)
+
+	if links > 1 && !info.IsDir() {
+		if c.seen == nil {
+			c.seen = make(map[fileID]uint64)
+		}
+		c.seen[id]++
+		switch n := c.seen[id]; {
+		case n == 1:
+			c.Usage.SharedSize += disk
+			c.Usage.ApparentSize += info.Size()
+			c.Usage.FileCount++
+		case n == links:
+			c.Usage.SharedSize -= disk
+			c.Usage.DiskSize += disk
+		}
+		return
+	}
+
+	c.Usage.DiskSize += disk
+	if !info.IsDir() {
+		c.Usage.ApparentSize += info.Size()
+		c.Usage.FileCount++
+	}
+}
+
+// MeasureDir 统计目录的空间占用
+func MeasureDir(path string) (DirUsage, error) {
+	return MeasureDirContext(context.Background(), path)
+}
+
+// MeasureDirContext 统计目录的空间占用，ctx 取消时立即停止并返回 ctx.Err()
+func MeasureDirContext(ctx context.Context, path string) (DirUsage, error) {
+	var counter UsageCounter
+
+	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		if err != nil {
+			// 忽略权限错误等
+			return nil
+		}
+
+		counter.Add(info)
+		return nil
+	})
+
+	return counter.Usage, err
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fast-clean-x/backend/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMeasureDir(t *testing.T) {
+	root := t.TempDir()
+
+	// 稀疏文件：内容大小 1 MB，几乎不占用磁盘空间
+	sparse, err := os.Create(filepath.Join(root, "sparse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sparse.Truncate(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	sparse.Close()
+
+	// 硬链接：同一个文件的两个链接只计算一次
+	data := filepath.Join(root, "data")
+	if err := os.WriteFile(data, make([]byte, 8192), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(data, filepath.Join(root, "data-link")); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	usage, err := MeasureDir(root)
+	if err != nil {
+		t.Fatalf("MeasureDir() error: %v", err)
+	}
+
+	if usage.FileCount != 2 {
+		t.Errorf("FileCount = %d, want 2 (hard link counted once)", usage.FileCount)
+	}
+	if want := int64(1<<20 + 8192); usage.ApparentSize != want {
+		t.Errorf("ApparentSize = %d, want %d", usage.ApparentSize, want)
+	}
+	if usage.DiskSize < 8192 || usage.DiskSize >= 1<<20 {
+		t.Errorf("DiskSize = %d, want allocated blocks of the regular file only", usage.DiskSize)
+	}
+	if usage.SharedSize != 0 {
+		t.Errorf("SharedSize = %d, want 0 (every link is inside the directory)", usage.SharedSize)
+	}
+	if usage.Size(models.SizeModeDisk) != usage.DiskSize || usage.Size(models.SizeModeApparent) != usage.ApparentSize {
+		t.Errorf("Size() does not follow the size mode: %+v", usage)
+	}
+}
+
+func TestMeasureDirExternalLinks(t *testing.T) {
+	root := t.TempDir()
+	store := filepath.Join(root, "store")
+	dir := filepath.Join(root, "node_modules")
+	for _, d := range []string{store, dir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 与 pnpm 全局存储共享的文件：删除 node_modules 不会释放空间
+	data := filepath.Join(store, "data")
+	if err := os.WriteFile(data, make([]byte, 8192), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(data, filepath.Join(dir, "data")); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	usage, err := MeasureDir(dir)
+	if err != nil {
+		t.Fatalf("MeasureDir() error: %v", err)
+	}
+	if usage.SharedSize < 8192 {
+		t.Errorf("SharedSize = %d, want the allocated blocks of the linked file", usage.SharedSize)
+	}
+	if usage.DiskSize >= 8192 {
+		t.Errorf("DiskSize = %d, want the directory only", usage.DiskSize)
+	}
+	if usage.ApparentSize != 8192 || usage.FileCount != 1 {
+		t.Errorf("ApparentSize = %d, FileCount = %d; want 8192, 1", usage.ApparentSize, usage.FileCount)
+	}
+
+	whole, err := MeasureDir(root)
+	if err != nil {
+		t.Fatalf("MeasureDir() error: %v", err)
+	}
+	if whole.SharedSize != 0 || whole.DiskSize < 8192 {
+		t.Errorf("MeasureDir(root) = %+v, want the linked file counted in DiskSize", whole)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package utils
+
+import "os"
+
+// fileUsage 无法获取分配的磁盘空间时使用文件内容大小，不做硬链接去重
+func fileUsage(info os.FileInfo) (int64, fileID, uint64) {
+	if info.IsDir() {
+		return 0, fileID{}, 1
+	}
+	return info.Size(), fileID{}, 1
+}
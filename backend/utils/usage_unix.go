@@ -0,0 +1,19 @@
+//go:build linux || darwin
+
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileUsage 返回文件实际分配的磁盘空间、文件标识和硬链接数量
+// st_blocks 的单位固定为 512 字节，稀疏文件小于内容大小，小文件按块大小向上取整
+func fileUsage(info os.FileInfo) (int64, fileID, uint64) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size(), fileID{}, 1
+	}
+	id := fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}
+	return int64(st.Blocks) * 512, id, uint64(st.Nlink)
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -30,13 +31,16 @@ func GetHomeDir() (string, error) {
 	return home, nil
 }
 
+// ConfigDirName 配置目录名称，位于用户主目录下
+const ConfigDirName = ".fast-clean-x"
+
 // GetConfigDir 获取配置目录
 func GetConfigDir() (string, error) {
 	home, err := GetHomeDir()
 	if err != nil {
 		return "", err
 	}
-	configDir := filepath.Join(home, ".fast-clean-x")
+	configDir := filepath.Join(home, ConfigDirName)
 
 	// 确保目录存在
 	if err := os.MkdirAll(configDir, 0755); err != nil {
@@ -64,6 +68,11 @@ func ShouldSkipDir(path string) bool {
 		return true
 	}
 
+	// 跳过本应用的配置目录（包含隔离区）
+	if name == ConfigDirName {
+		return true
+	}
+
 	// 跳过系统目录
 	systemDirs := []string{
 		"System Volume Information",
@@ -161,11 +170,21 @@ func hasProjectMarker(dir string) bool {
 		"package.json",
 		"Cargo.toml",
 		"go.mod",
+		"*.csproj",
+		"*.fsproj",
+		"CMakeLists.txt",
+		"Package.swift",
+		"pubspec.yaml",
+		"mix.exs",
+		"cabal.project",
+		"stack.yaml",
+		"build.zig",
+		"composer.json",
+		"Gemfile",
 	}
 
 	for _, marker := range markers {
-		markerPath := filepath.Join(dir, marker)
-		if _, err := os.Stat(markerPath); err == nil {
+		if HasMarker(dir, marker) {
 			return true
 		}
 	}
@@ -173,28 +192,6 @@ func hasProjectMarker(dir string) bool {
 	return false
 }
 
-// CalculateDirSize 计算目录大小
-func CalculateDirSize(path string) (int64, int, error) {
-	var size int64
-	var count int
-
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			// 忽略权限错误等
-			return nil
-		}
-
-		if !info.IsDir() {
-			size += info.Size()
-			count++
-		}
-
-		return nil
-	})
-
-	return size, count, err
-}
-
 // PathExists 检查路径是否存在
 func PathExists(path string) bool {
 	_, err := os.Stat(path)
@@ -210,6 +207,25 @@ func IsDirectory(path string) bool {
 	return info.IsDir()
 }
 
+// RemoveAll 删除文件或目录，其中有只读目录（如 Go 模块缓存、权限为 0555 的依赖目录）时
+// 先恢复写权限再删除
+func RemoveAll(path string) error {
+	if err := os.RemoveAll(path); err == nil {
+		return nil
+	}
+
+	filepath.WalkDir(path, func(p string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() {
+			os.Chmod(p, 0755)
+		}
+		return nil
+	})
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
 // FindNearestMarker 从指定路径向上查找最近的项目标识文件
 // 返回找到的标识文件所在的目录路径，如果没找到返回空字符串
 func FindNearestMarker(startPath string, markers []string) string {
@@ -225,8 +241,7 @@ func FindNearestMarker(startPath string, markers []string) string {
 
 		// 检查当前目录是否有任一标识文件
 		for _, marker := range markers {
-			markerPath := filepath.Join(current, marker)
-			if _, err := os.Stat(markerPath); err == nil {
+			if HasMarker(current, marker) {
 				return current
 			}
 		}
@@ -237,6 +252,41 @@ func FindNearestMarker(startPath string, markers []string) string {
 	return ""
 }
 
+// HasMarker 检查目录中是否有项目标识文件
+// 标识可以是通配符模式（如 *.csproj），此时读取目录项逐一匹配
+func HasMarker(dir, marker string) bool {
+	if !strings.ContainsAny(marker, "*?[") {
+		return PathExists(filepath.Join(dir, marker))
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	for {
+		names, err := f.Readdirnames(256)
+		for _, name := range names {
+			if matched, _ := filepath.Match(marker, name); matched {
+				return true
+			}
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
+
+// IsUnder 判断 path 是否位于 root 之下，root 本身不算
+func IsUnder(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
 // Contains 检查路径中是否包含指定的子路径
 func Contains(path string, substr string) bool {
 	return strings.Contains(path, string(filepath.Separator)+substr+string(filepath.Separator)) ||
@@ -0,0 +1,11 @@
+// fast-clean-x 命令行版本，不依赖桌面界面，可用于 CI 或 SSH 环境
+package main
+
+import (
+	"fast-clean-x/backend/cli"
+	"os"
+)
+
+func main() {
+	os.Exit(cli.New(os.Stdin, os.Stdout, os.Stderr).Run(os.Args[1:]))
+}